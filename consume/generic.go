@@ -0,0 +1,199 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+)
+
+// BufferOf works like Buffer except that it stores values in a []T
+// without using reflection.
+type BufferOf[T any] struct {
+  buffer []T
+  idx int
+}
+
+// NewBufferOf creates a new BufferOf. aSlice is used to store values.
+func NewBufferOf[T any](aSlice []T) *BufferOf[T] {
+  return &BufferOf[T]{buffer: aSlice}
+}
+
+// Values returns the values gathered from the last Consume call. The number
+// of values gathered will not exceed the length of the original slice passed
+// to NewBufferOf. Returned slice remains valid until the next call to Consume.
+func (b *BufferOf[T]) Values() []T {
+  return b.buffer[:b.idx]
+}
+
+// Consume fetches the values. s is a Stream of T.
+func (b *BufferOf[T]) Consume(s functional.Stream) (err error) {
+  b.idx, err = readStreamIntoSliceOf(s, b.buffer)
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+// Collect works like AppendTo except that it appends to a []T without
+// using reflection. aSlicePointer points to the []T which is updated in place.
+func Collect[T any](aSlicePointer *[]T) functional.Consumer {
+  return &collectConsumer[T]{buffer: aSlicePointer}
+}
+
+// CollectPtrs works like AppendPtrsTo except that it appends to a []*T without
+// using reflection. aSlicePointer points to the []*T which is updated in
+// place. creater allocates space to store one T value. nil means new(T).
+func CollectPtrs[T any](
+    aSlicePointer *[]*T, creater func() *T) functional.Consumer {
+  if creater == nil {
+    creater = newOf[T]
+  }
+  return &collectPtrsConsumer[T]{buffer: aSlicePointer, creater: creater}
+}
+
+// PageBufferOf works like PageBuffer except that it stores values in a []T
+// without using reflection.
+type PageBufferOf[T any] struct {
+  buffer []T
+  desired_page_no int
+  pageLen int
+  page_no int
+  is_end bool
+  idx int
+}
+
+// NewPageBufferOf returns a new PageBufferOf instance.
+// aSlice has a length that is double that of each page;
+// desiredPageNo is the desired 0-based page number. NewPageBufferOf panics
+// if the length of aSlice is odd.
+func NewPageBufferOf[T any](aSlice []T, desiredPageNo int) *PageBufferOf[T] {
+  l := len(aSlice)
+  if l % 2 == 1 {
+    panic("Slice passed to NewPageBufferOf must have even length.")
+  }
+  if l == 0 {
+    panic("Slice passed to NewPageBufferOf must have non-zero length.")
+  }
+  return &PageBufferOf[T]{
+      buffer: aSlice,
+      desired_page_no: desiredPageNo,
+      pageLen: l / 2}
+}
+
+// Values returns the values of the fetched page. Returned slice is valid
+// until next call to Consume.
+func (pb *PageBufferOf[T]) Values() []T {
+  offset := pb.pageOffset(pb.page_no)
+  return pb.buffer[offset:offset + pb.idx]
+}
+
+// PageNo returns the 0-based page number of fetched page. Note that this
+// returned page number may be less than the desired page number if the
+// Stream passed to Consume becomes exhaused.
+func (pb *PageBufferOf[T]) PageNo() int {
+  return pb.page_no
+}
+
+// End returns true if last page reached.
+func (pb *PageBufferOf[T]) End() bool {
+  return pb.is_end
+}
+
+// Consume fetches the values. s is a Stream of T.
+func (pb *PageBufferOf[T]) Consume(s functional.Stream) (err error) {
+  pb.page_no = 0
+  pb.idx = 0
+  pb.is_end = false
+  for err == nil && !pb.isDesiredPageRead() {
+    if pb.idx > 0 {
+      pb.page_no++
+    }
+    offset := pb.pageOffset(pb.page_no)
+    pb.idx, err = readStreamIntoSliceOf(
+        s, pb.buffer[offset:offset + pb.pageLen])
+  }
+  if err == nil {
+    anElement := &pb.buffer[pb.pageOffset(pb.page_no + 1)]
+    pb.is_end = s.Next(anElement) == functional.Done
+  } else if err == functional.Done {
+    pb.is_end = true
+    err = nil
+    if pb.page_no > 0 && pb.idx == 0 {
+      pb.page_no--
+      pb.idx = pb.pageLen
+    }
+  }
+  return
+}
+
+func (pb *PageBufferOf[T]) pageOffset(pageNo int) int {
+  return (pageNo % 2) * pb.pageLen
+}
+
+func (pb *PageBufferOf[T]) isDesiredPageRead() bool {
+  if pb.idx == 0 {
+    return false
+  }
+  return pb.page_no >= pb.desired_page_no
+}
+
+type collectConsumer[T any] struct {
+  buffer *[]T
+}
+
+func (c *collectConsumer[T]) Consume(s functional.Stream) (err error) {
+  buffer := *c.buffer
+  for err == nil {
+    if len(buffer) == cap(buffer) {
+      newBuffer := make([]T, len(buffer), 2 * cap(buffer) + 1)
+      copy(newBuffer, buffer)
+      buffer = newBuffer
+    }
+    var numRead int
+    numRead, err = readStreamIntoSliceOf(s, buffer[len(buffer):cap(buffer)])
+    buffer = buffer[:len(buffer) + numRead]
+    *c.buffer = buffer
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+type collectPtrsConsumer[T any] struct {
+  buffer *[]*T
+  creater func() *T
+}
+
+func (c *collectPtrsConsumer[T]) Consume(s functional.Stream) (err error) {
+  for {
+    ptr := c.creater()
+    if err = s.Next(ptr); err != nil {
+      break
+    }
+    *c.buffer = append(*c.buffer, ptr)
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+func readStreamIntoSliceOf[T any](
+    s functional.Stream, aSlice []T) (numRead int, err error) {
+  l := len(aSlice)
+  for numRead = 0; numRead < l; numRead++ {
+    err = s.Next(&aSlice[numRead])
+    if err != nil {
+      break
+    }
+  }
+  return
+}
+
+func newOf[T any]() *T {
+  return new(T)
+}
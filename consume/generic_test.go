@@ -0,0 +1,150 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional3/functional"
+  "testing"
+)
+
+func TestBufferOfSameSize(t *testing.T) {
+  stream := functional.Slice(functional.Count(), 0, 5)
+  b := NewBufferOf(make([]int, 5))
+  doConsume(t, b, stream, nil)
+  verifyValues(t, b.Values(), 0, 5)
+}
+
+func TestBufferOfSmall(t *testing.T) {
+  stream := functional.Slice(functional.Count(), 0, 6)
+  b := NewBufferOf(make([]int, 5))
+  doConsume(t, b, stream, nil)
+  verifyValues(t, b.Values(), 0, 5)
+}
+
+func TestBufferOfBig(t *testing.T) {
+  stream := functional.Slice(functional.Count(), 0, 4)
+  b := NewBufferOf(make([]int, 5))
+  doConsume(t, b, stream, nil)
+  verifyValues(t, b.Values(), 0, 4)
+}
+
+func TestBufferOfError(t *testing.T) {
+  stream := errorStream{otherError}
+  b := NewBufferOf(make([]int, 5))
+  doConsume(t, b, stream, otherError)
+}
+
+func TestCollect(t *testing.T) {
+  var values []int
+  stream := functional.Slice(functional.Count(), 0, 7)
+  doConsume(t, Collect(&values), stream, nil)
+  verifyValues(t, values, 0, 7)
+  if actual := cap(values); actual != 15 {
+    t.Errorf("Expected capacity of 15, got %v", actual)
+  }
+}
+
+func TestCollect2(t *testing.T) {
+  values := []int{1, 2}
+  c := Collect(&values)
+  stream := functional.Slice(functional.Count(), 3, 7)
+  doConsume(t, c, stream, nil)
+  stream = functional.Slice(functional.Count(), 7, 11)
+  doConsume(t, c, stream, nil)
+  verifyValues(t, values, 1, 11)
+  if actual := cap(values); actual != 11 {
+    t.Errorf("Expected capacity of 11, got %v", actual)
+  }
+}
+
+func TestCollectError(t *testing.T) {
+  var values []int
+  doConsume(t, Collect(&values), errorStream{otherError}, otherError)
+  if actual := len(values); actual != 0 {
+    t.Errorf("Expected length of 0, got %v", actual)
+  }
+}
+
+func ExampleCollect() {
+  values := []int{5}
+  stream := functional.Slice(functional.Count(), 0, 2)
+  Collect(&values).Consume(stream)
+  for i := range values {
+    fmt.Println(values[i])
+  }
+  // Output:
+  // 5
+  // 0
+  // 1
+}
+
+func TestCollectPtrs(t *testing.T) {
+  var values []*int
+  stream := functional.Slice(functional.Count(), 0, 7)
+  doConsume(t, CollectPtrs(&values, nil), stream, nil)
+  verifyPtrValues(t, values, 0, 7)
+}
+
+func TestCollectPtrs2(t *testing.T) {
+  var values []*int
+  stream := functional.Slice(functional.Count(), 0, 3)
+  var x int
+  // Our creater returns a pointer to the same variable.
+  creater := func() *int {
+    return &x
+  }
+  doConsume(t, CollectPtrs(&values, creater), stream, nil)
+  // We should have a slice of length 3 with all pointers being the same.
+  if len(values) != 3 || values[0] != values[1] || values[0] != values[2] {
+    t.Error("Failure")
+  }
+}
+
+func TestPageBufferOf(t *testing.T) {
+  verifyPageBufferOf(t, functional.Count(), 0, 0, 3, 0, false)
+  verifyPageBufferOf(t, functional.Count(), 1, 3, 6, 1, false)
+  verifyPageBufferOf(t, functional.Count(), 2, 6, 9, 2, false)
+  verifyPageBufferOf(t, functional.Count(), -1, 0, 3, 0, false)
+  verifyPageBufferOf(t, xrange(0, 7), 2, 6, 7, 2, true)
+  verifyPageBufferOf(t, xrange(0, 7), 3, 6, 7, 2, true)
+  verifyPageBufferOf(t, xrange(0, 6), 2, 3, 6, 1, true)
+  verifyPageBufferOf(t, xrange(0, 6), 3, 3, 6, 1, true)
+  verifyPageBufferOf(t, xrange(0, 6), 1, 3, 6, 1, true)
+  verifyPageBufferOf(t, xrange(0, 1), 0, 0, 1, 0, true)
+  verifyPageBufferOf(t, functional.NilStream(), 0, 0, 0, 0, true)
+  verifyPageBufferOf(t, functional.NilStream(), 1, 0, 0, 0, true)
+  verifyPageBufferOf(t, functional.NilStream(), -1, 0, 0, 0, true)
+}
+
+func TestPageBufferOfError(t *testing.T) {
+  stream := errorStream{otherError}
+  b := NewPageBufferOf(make([]int, 6), 0)
+  doConsume(t, b, stream, otherError)
+}
+
+func verifyPageBufferOf(
+    t *testing.T,
+    s functional.Stream,
+    desiredPageNo int,
+    start int,
+    end int,
+    page_no int,
+    is_end bool) {
+  pb := NewPageBufferOf(make([]int, 6), desiredPageNo)
+  doConsume(t, pb, s, nil)
+  verifyValues(t, pb.Values(), start, end)
+  if output := pb.PageNo(); output != page_no {
+    t.Errorf("Expected page %v, got %v", page_no, output)
+  }
+  if output := pb.End(); output != is_end {
+    t.Errorf("For end, expected %v, got %v", is_end, output)
+  }
+}
+
+func xrange(start, end int) functional.Stream {
+  return functional.Slice(functional.Count(), start, end)
+}
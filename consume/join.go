@@ -0,0 +1,177 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "errors"
+  "github.com/keep94/gofunctional3/functional"
+)
+
+var (
+  // ErrBuildTooLarge indicates that a Multimap has more values than it
+  // is allowed to hold.
+  ErrBuildTooLarge = errors.New("consume: Too many values to build in memory.")
+)
+
+// Multimap is an Aggregator that maps keys of type K to the T values it
+// consumes.
+type Multimap[K comparable] struct {
+  key func(ptr interface{}) K
+  creater functional.Creater
+  maxValues int
+  values map[K][]interface{}
+  count int
+}
+
+// NewMultimap returns a new Multimap. key returns the key of the T value at
+// ptr. creater is a Creater of T. maxValues is the most T values the
+// Multimap may hold; 0 means no limit.
+func NewMultimap[K comparable](
+    key func(ptr interface{}) K,
+    creater functional.Creater,
+    maxValues int) *Multimap[K] {
+  return &Multimap[K]{
+      key: key,
+      creater: creater,
+      maxValues: maxValues,
+      values: make(map[K][]interface{})}
+}
+
+// Consume builds a new multimap from s, a Stream of T. Consume returns
+// ErrBuildTooLarge if s has more than maxValues values. Consume stops and
+// returns the error if s reports an error.
+func (m *Multimap[K]) Consume(s functional.Stream) (err error) {
+  m.values = make(map[K][]interface{})
+  m.count = 0
+  ptr := m.creater()
+  for err = s.Next(ptr); err == nil; err = s.Next(ptr) {
+    if m.maxValues > 0 && m.count == m.maxValues {
+      return ErrBuildTooLarge
+    }
+    k := m.key(ptr)
+    m.values[k] = append(m.values[k], ptr)
+    m.count++
+    ptr = m.creater()
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+// Get returns the T values with key k from the last call to Consume as *T
+// values in the order they were consumed. Caller must not modify returned
+// slice.
+func (m *Multimap[K]) Get(k K) []interface{} {
+  return m.values[k]
+}
+
+// Len returns the number of T values from the last call to Consume.
+func (m *Multimap[K]) Len() int {
+  return m.count
+}
+
+// Result returns this instance.
+func (m *Multimap[K]) Result() interface{} {
+  return m
+}
+
+// HashJoin joins probe, a Stream of T, with build, a Stream of U. Unlike
+// functional.MergeJoin, neither Stream has to be sorted, but HashJoin
+// reads all of build into a Multimap, so build should be the smaller
+// side. probeKey returns the key of the T value at its ptr; buildKey
+// returns the key of the U value at its ptr. The returned Stream emits a
+// *functional.Pair for each probe row and each build row with the same key,
+// in the order of probe. kind must be functional.InnerJoin,
+// functional.LeftJoin, or functional.AntiJoin; otherwise HashJoin panics.
+// The Left field of emitted Pairs is a *T that remains valid until the next
+// call to Next; the Right field is a *U that remains valid until Close or
+// nil if there is no matching build row. probeCreater is a Creater of T;
+// buildCreater is a Creater of U. maxBuildValues is as in NewMultimap.
+// HashJoin reads build on the first call to Next. If reading build fails,
+// including with ErrBuildTooLarge, Next reports the error once and then
+// returns functional.Done. Errors from probe pass through.
+// Calling Close on returned Stream closes probe and build.
+func HashJoin[K comparable](
+    probe functional.Stream,
+    build functional.Stream,
+    probeKey func(ptr interface{}) K,
+    buildKey func(ptr interface{}) K,
+    probeCreater functional.Creater,
+    buildCreater functional.Creater,
+    maxBuildValues int,
+    kind functional.JoinKind) functional.Stream {
+  if kind != functional.InnerJoin && kind != functional.LeftJoin && kind != functional.AntiJoin {
+    panic("HashJoin supports only InnerJoin, LeftJoin, and AntiJoin.")
+  }
+  return &hashJoinStream[K]{
+      probe: probe,
+      build: build,
+      probeKey: probeKey,
+      table: NewMultimap(buildKey, buildCreater, maxBuildValues),
+      kind: kind,
+      row: probeCreater()}
+}
+
+type hashJoinStream[K comparable] struct {
+  probe functional.Stream
+  build functional.Stream
+  probeKey func(ptr interface{}) K
+  table *Multimap[K]
+  kind functional.JoinKind
+  started bool
+  // failed is true if reading build failed.
+  failed bool
+  // row is the current probe row.
+  row interface{}
+  // matches are the build rows for row not yet emitted.
+  matches []interface{}
+}
+
+func (s *hashJoinStream[K]) Next(ptr interface{}) error {
+  if !s.started {
+    s.started = true
+    if err := s.table.Consume(s.build); err != nil {
+      s.failed = true
+      return err
+    }
+  }
+  if s.failed {
+    return functional.Done
+  }
+  for {
+    if len(s.matches) > 0 {
+      emitPair(ptr, s.row, s.matches[0])
+      s.matches = s.matches[1:]
+      return nil
+    }
+    if err := s.probe.Next(s.row); err != nil {
+      return err
+    }
+    matches := s.table.Get(s.probeKey(s.row))
+    if len(matches) == 0 && s.kind != functional.InnerJoin {
+      emitPair(ptr, s.row, nil)
+      return nil
+    }
+    if s.kind != functional.AntiJoin {
+      s.matches = matches
+    }
+  }
+}
+
+func (s *hashJoinStream[K]) Close() error {
+  result := s.probe.Close()
+  if err := s.build.Close(); result == nil {
+    result = err
+  }
+  return result
+}
+
+func emitPair(ptr interface{}, l, r interface{}) {
+  p := ptr.(*functional.Pair)
+  p.Left = l
+  p.Right = r
+}
@@ -0,0 +1,120 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional3/functional"
+  "testing"
+)
+
+func TestMultimap(t *testing.T) {
+  m := NewMultimap(tensKey, intCreater, 0)
+  doConsume(t, m, functional.NewStreamFromValues([]int{10, 30, 12, 31}, nil), nil)
+  if output := m.Len(); output != 4 {
+    t.Errorf("Expected 4, got %v", output)
+  }
+  if output := toInts(m.Get(1)); output != "[10 12]" {
+    t.Errorf("Expected [10 12], got %v", output)
+  }
+  if output := toInts(m.Get(2)); output != "[]" {
+    t.Errorf("Expected [], got %v", output)
+  }
+  // Each call to Consume starts over.
+  doConsume(t, m, xrange(0, 3), nil)
+  if output := toInts(m.Get(1)); output != "[]" {
+    t.Errorf("Expected [], got %v", output)
+  }
+  if output := m.Len(); output != 3 {
+    t.Errorf("Expected 3, got %v", output)
+  }
+}
+
+func TestMultimapTooLarge(t *testing.T) {
+  m := NewMultimap(tensKey, intCreater, 3)
+  doConsume(t, m, xrange(0, 3), nil)
+  doConsume(t, m, xrange(0, 4), ErrBuildTooLarge)
+}
+
+func TestHashJoin(t *testing.T) {
+  expected := map[functional.JoinKind]string{
+      functional.InnerJoin: "[1:10 3:30 3:31]",
+      functional.LeftJoin: "[0:- 1:10 2:- 3:30 3:31 4:-]",
+      functional.AntiJoin: "[0:- 2:- 4:-]",
+  }
+  for kind, want := range expected {
+    s := HashJoin(
+        xrange(0, 5),
+        functional.NewStreamFromValues([]int{30, 10, 31, 70}, nil),
+        identityKey,
+        tensKey,
+        intCreater,
+        intCreater,
+        0,
+        kind)
+    results, err := joinToStrings(s)
+    if output := fmt.Sprintf("%v", results); output != want {
+      t.Errorf("Kind %d: Expected %v, got %v", kind, want, output)
+    }
+    if err != functional.Done {
+      t.Errorf("Expected Done, got %v", err)
+    }
+  }
+}
+
+func TestHashJoinBuildTooLarge(t *testing.T) {
+  s := HashJoin(
+      xrange(0, 5),
+      xrange(0, 5),
+      identityKey,
+      identityKey,
+      intCreater,
+      intCreater,
+      4,
+      functional.InnerJoin)
+  var p functional.Pair
+  if err := s.Next(&p); err != ErrBuildTooLarge {
+    t.Errorf("Expected ErrBuildTooLarge, got %v", err)
+  }
+  if err := s.Next(&p); err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+}
+
+func TestHashJoinClose(t *testing.T) {
+  s := HashJoin(
+      xrange(0, 5),
+      closeErrorStream{xrange(0, 5)},
+      identityKey,
+      identityKey,
+      intCreater,
+      intCreater,
+      0,
+      functional.InnerJoin)
+  if err := s.Close(); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+}
+
+func identityKey(ptr interface{}) int {
+  return *ptr.(*int)
+}
+
+func tensKey(ptr interface{}) int {
+  return *ptr.(*int) / 10
+}
+
+func joinToStrings(s functional.Stream) (result []string, err error) {
+  var p functional.Pair
+  for err = s.Next(&p); err == nil; err = s.Next(&p) {
+    r := "-"
+    if p.Right != nil {
+      r = fmt.Sprintf("%d", *p.Right.(*int))
+    }
+    result = append(result, fmt.Sprintf("%d:%s", *p.Left.(*int), r))
+  }
+  return
+}
@@ -0,0 +1,328 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "encoding/binary"
+  "errors"
+  "github.com/keep94/gofunctional3/functional"
+  "math"
+  "sort"
+)
+
+const (
+  quantileSketchVersion = 1
+)
+
+var (
+  // ErrAccuracyMismatch indicates an attempt to merge QuantileSketches with
+  // different relative accuracies.
+  ErrAccuracyMismatch = errors.New("consume: Sketches have different relative accuracies.")
+  // ErrBadSketchData indicates that data passed to
+  // QuantileSketch.UnmarshalBinary is not a valid sketch.
+  ErrBadSketchData = errors.New("consume: Bad sketch data.")
+)
+
+// QuantileSketch estimates quantiles of a set of numbers in a small amount
+// of memory. Each estimate is within a fixed relative error of an actual
+// value from the set at the requested rank. QuantileSketch is a DDSketch:
+// it counts numbers in buckets whose bounds grow geometrically, so its size
+// depends on the range of the numbers rather than on how many there are.
+// QuantileSketches with the same relative accuracy can be merged.
+type QuantileSketch struct {
+  relativeAccuracy float64
+  logGamma float64
+  // positive and negative map bucket index to count. Bucket i holds
+  // numbers whose absolute value is in (gamma^(i-1), gamma^i].
+  positive map[int]int64
+  negative map[int]int64
+  zeroCount int64
+  count int64
+  min float64
+  max float64
+}
+
+// NewQuantileSketch returns a new, empty QuantileSketch. relativeAccuracy is
+// the maximum relative error of each estimate, e.g 0.01 for 1%.
+// NewQuantileSketch panics if relativeAccuracy is not between 0 and 1.
+func NewQuantileSketch(relativeAccuracy float64) *QuantileSketch {
+  if !(relativeAccuracy > 0.0 && relativeAccuracy < 1.0) {
+    panic("relativeAccuracy must be between 0 and 1.")
+  }
+  gamma := (1.0 + relativeAccuracy) / (1.0 - relativeAccuracy)
+  return &QuantileSketch{
+      relativeAccuracy: relativeAccuracy,
+      logGamma: math.Log(gamma),
+      positive: make(map[int]int64),
+      negative: make(map[int]int64)}
+}
+
+// RelativeAccuracy returns the relative accuracy of this instance.
+func (s *QuantileSketch) RelativeAccuracy() float64 {
+  return s.relativeAccuracy
+}
+
+// Count returns how many numbers have been added to this instance.
+func (s *QuantileSketch) Count() int64 {
+  return s.count
+}
+
+// Add adds x to this instance. Add ignores NaN and infinite values.
+func (s *QuantileSketch) Add(x float64) {
+  if math.IsNaN(x) || math.IsInf(x, 0) {
+    return
+  }
+  switch {
+  case x > 0.0:
+    s.positive[s.index(x)]++
+  case x < 0.0:
+    s.negative[s.index(-x)]++
+  default:
+    s.zeroCount++
+  }
+  if s.count == 0 || x < s.min {
+    s.min = x
+  }
+  if s.count == 0 || x > s.max {
+    s.max = x
+  }
+  s.count++
+}
+
+// Quantile returns an estimate of the qth quantile of the numbers added to
+// this instance, where q is between 0 and 1. For example, Quantile(0.99)
+// estimates the 99th percentile. Quantile returns NaN if this instance is
+// empty or q is not between 0 and 1.
+func (s *QuantileSketch) Quantile(q float64) float64 {
+  if s.count == 0 || !(q >= 0.0 && q <= 1.0) {
+    return math.NaN()
+  }
+  rank := int64(q * float64(s.count - 1))
+  var seen int64
+  negIndexes := sortedIndexes(s.negative)
+  for i := len(negIndexes) - 1; i >= 0; i-- {
+    seen += s.negative[negIndexes[i]]
+    if seen > rank {
+      return s.clamp(-s.value(negIndexes[i]))
+    }
+  }
+  seen += s.zeroCount
+  if seen > rank {
+    return 0.0
+  }
+  for _, idx := range sortedIndexes(s.positive) {
+    seen += s.positive[idx]
+    if seen > rank {
+      return s.clamp(s.value(idx))
+    }
+  }
+  return s.max
+}
+
+// Merge adds the numbers in other to this instance. Merge returns
+// ErrAccuracyMismatch and leaves this instance unchanged if other has a
+// different relative accuracy.
+func (s *QuantileSketch) Merge(other *QuantileSketch) error {
+  if other.relativeAccuracy != s.relativeAccuracy {
+    return ErrAccuracyMismatch
+  }
+  if other.count == 0 {
+    return nil
+  }
+  for idx, count := range other.positive {
+    s.positive[idx] += count
+  }
+  for idx, count := range other.negative {
+    s.negative[idx] += count
+  }
+  s.zeroCount += other.zeroCount
+  if s.count == 0 || other.min < s.min {
+    s.min = other.min
+  }
+  if s.count == 0 || other.max > s.max {
+    s.max = other.max
+  }
+  s.count += other.count
+  return nil
+}
+
+// MarshalBinary encodes this instance so that it can be saved and later
+// restored with UnmarshalBinary.
+func (s *QuantileSketch) MarshalBinary() ([]byte, error) {
+  result := []byte{quantileSketchVersion}
+  result = binary.LittleEndian.AppendUint64(
+      result, math.Float64bits(s.relativeAccuracy))
+  result = binary.AppendUvarint(result, uint64(s.zeroCount))
+  result = binary.LittleEndian.AppendUint64(result, math.Float64bits(s.min))
+  result = binary.LittleEndian.AppendUint64(result, math.Float64bits(s.max))
+  result = appendBuckets(result, s.positive)
+  result = appendBuckets(result, s.negative)
+  return result, nil
+}
+
+// UnmarshalBinary replaces this instance with a QuantileSketch encoded with
+// MarshalBinary. UnmarshalBinary returns ErrBadSketchData if data is not
+// valid.
+func (s *QuantileSketch) UnmarshalBinary(data []byte) error {
+  if len(data) < 1 || data[0] != quantileSketchVersion {
+    return ErrBadSketchData
+  }
+  r := &sketchReader{data: data[1:]}
+  relativeAccuracy := math.Float64frombits(r.uint64())
+  zeroCount := int64(r.uvarint())
+  min := math.Float64frombits(r.uint64())
+  max := math.Float64frombits(r.uint64())
+  positive := r.buckets()
+  negative := r.buckets()
+  if r.bad || len(r.data) != 0 || !(relativeAccuracy > 0.0 && relativeAccuracy < 1.0) {
+    return ErrBadSketchData
+  }
+  result := NewQuantileSketch(relativeAccuracy)
+  result.positive = positive
+  result.negative = negative
+  result.zeroCount = zeroCount
+  result.count = zeroCount + total(positive) + total(negative)
+  result.min = min
+  result.max = max
+  *s = *result
+  return nil
+}
+
+func (s *QuantileSketch) index(x float64) int {
+  return int(math.Ceil(math.Log(x) / s.logGamma))
+}
+
+// value returns the estimate for the numbers in bucket idx.
+func (s *QuantileSketch) value(idx int) float64 {
+  gamma := math.Exp(s.logGamma)
+  return 2.0 * math.Exp(float64(idx) * s.logGamma) / (gamma + 1.0)
+}
+
+func (s *QuantileSketch) clamp(x float64) float64 {
+  return math.Max(s.min, math.Min(s.max, x))
+}
+
+// Quantiles is an Aggregator that builds a QuantileSketch from the T values
+// it consumes.
+type Quantiles[T any] struct {
+  relativeAccuracy float64
+  extractor func(ptr *T) float64
+  sketch *QuantileSketch
+}
+
+// NewQuantiles returns a new Quantiles. relativeAccuracy is as in
+// NewQuantileSketch. extractor returns the number to add to the sketch
+// from the T value at ptr.
+func NewQuantiles[T any](
+    relativeAccuracy float64, extractor func(ptr *T) float64) *Quantiles[T] {
+  return &Quantiles[T]{
+      relativeAccuracy: relativeAccuracy,
+      extractor: extractor,
+      sketch: NewQuantileSketch(relativeAccuracy)}
+}
+
+// Consume builds a new QuantileSketch from stream, a Stream of T. Consume
+// stops and returns the error if stream reports an error.
+func (q *Quantiles[T]) Consume(stream functional.Stream) (err error) {
+  q.sketch = NewQuantileSketch(q.relativeAccuracy)
+  var value T
+  for err = stream.Next(&value); err == nil; err = stream.Next(&value) {
+    q.sketch.Add(q.extractor(&value))
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+// Sketch returns the QuantileSketch from the last call to Consume.
+func (q *Quantiles[T]) Sketch() *QuantileSketch {
+  return q.sketch
+}
+
+// Quantile is shorthand for Sketch().Quantile(x).
+func (q *Quantiles[T]) Quantile(x float64) float64 {
+  return q.sketch.Quantile(x)
+}
+
+// Result returns the QuantileSketch from the last call to Consume as an
+// interface{}.
+func (q *Quantiles[T]) Result() interface{} {
+  return q.sketch
+}
+
+func appendBuckets(data []byte, buckets map[int]int64) []byte {
+  data = binary.AppendUvarint(data, uint64(len(buckets)))
+  for _, idx := range sortedIndexes(buckets) {
+    data = binary.AppendVarint(data, int64(idx))
+    data = binary.AppendUvarint(data, uint64(buckets[idx]))
+  }
+  return data
+}
+
+func sortedIndexes(buckets map[int]int64) []int {
+  result := make([]int, 0, len(buckets))
+  for idx := range buckets {
+    result = append(result, idx)
+  }
+  sort.Ints(result)
+  return result
+}
+
+func total(buckets map[int]int64) (result int64) {
+  for _, count := range buckets {
+    result += count
+  }
+  return
+}
+
+// sketchReader decodes what MarshalBinary encodes. bad becomes true if data
+// runs out or is malformed.
+type sketchReader struct {
+  data []byte
+  bad bool
+}
+
+func (r *sketchReader) uint64() uint64 {
+  if len(r.data) < 8 {
+    r.bad = true
+    return 0
+  }
+  result := binary.LittleEndian.Uint64(r.data)
+  r.data = r.data[8:]
+  return result
+}
+
+func (r *sketchReader) uvarint() uint64 {
+  result, n := binary.Uvarint(r.data)
+  if n <= 0 {
+    r.bad = true
+    return 0
+  }
+  r.data = r.data[n:]
+  return result
+}
+
+func (r *sketchReader) varint() int64 {
+  result, n := binary.Varint(r.data)
+  if n <= 0 {
+    r.bad = true
+    return 0
+  }
+  r.data = r.data[n:]
+  return result
+}
+
+func (r *sketchReader) buckets() map[int]int64 {
+  length := r.uvarint()
+  result := make(map[int]int64)
+  for i := uint64(0); i < length && !r.bad; i++ {
+    idx := r.varint()
+    count := r.uvarint()
+    result[int(idx)] += int64(count)
+  }
+  return result
+}
@@ -0,0 +1,128 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+  "math"
+  "testing"
+)
+
+func TestQuantiles(t *testing.T) {
+  quantiles := NewQuantiles(0.01, intExtractor)
+  doConsume(t, quantiles, xrange(1, 1001), nil)
+  verifyQuantile(t, quantiles.Sketch(), 0.0, 1.0)
+  verifyQuantile(t, quantiles.Sketch(), 0.5, 500.0)
+  verifyQuantile(t, quantiles.Sketch(), 0.95, 950.0)
+  verifyQuantile(t, quantiles.Sketch(), 0.99, 990.0)
+  verifyQuantile(t, quantiles.Sketch(), 1.0, 1000.0)
+  if output := quantiles.Sketch().Count(); output != 1000 {
+    t.Errorf("Expected 1000, got %v", output)
+  }
+  if quantiles.Result() != quantiles.Sketch() {
+    t.Error("Expected Result to return the sketch.")
+  }
+  // Each call to Consume starts over.
+  doConsume(t, quantiles, xrange(0, 0), nil)
+  if output := quantiles.Quantile(0.5); !math.IsNaN(output) {
+    t.Errorf("Expected NaN, got %v", output)
+  }
+}
+
+func TestQuantileSketchNegativeAndZero(t *testing.T) {
+  sketch := NewQuantileSketch(0.02)
+  for i := -50; i <= 50; i++ {
+    sketch.Add(float64(i))
+  }
+  sketch.Add(math.NaN())
+  sketch.Add(math.Inf(1))
+  verifyQuantile(t, sketch, 0.0, -50.0)
+  verifyQuantile(t, sketch, 0.1, -40.0)
+  verifyQuantile(t, sketch, 0.5, 0.0)
+  verifyQuantile(t, sketch, 0.9, 40.0)
+  verifyQuantile(t, sketch, 1.0, 50.0)
+  if output := sketch.Count(); output != 101 {
+    t.Errorf("Expected 101, got %v", output)
+  }
+  if output := sketch.Quantile(1.5); !math.IsNaN(output) {
+    t.Errorf("Expected NaN, got %v", output)
+  }
+}
+
+func TestQuantileSketchMerge(t *testing.T) {
+  first := NewQuantiles(0.01, intExtractor)
+  doConsume(t, first, xrange(1, 300), nil)
+  second := NewQuantiles(0.01, intExtractor)
+  doConsume(t, second, xrange(300, 1001), nil)
+  sketch := NewQuantileSketch(0.01)
+  if err := sketch.Merge(first.Sketch()); err != nil {
+    t.Fatalf("Got error merging: %v", err)
+  }
+  if err := sketch.Merge(second.Sketch()); err != nil {
+    t.Fatalf("Got error merging: %v", err)
+  }
+  verifyQuantile(t, sketch, 0.0, 1.0)
+  verifyQuantile(t, sketch, 0.5, 500.0)
+  verifyQuantile(t, sketch, 0.99, 990.0)
+  verifyQuantile(t, sketch, 1.0, 1000.0)
+  if err := sketch.Merge(NewQuantileSketch(0.02)); err != ErrAccuracyMismatch {
+    t.Errorf("Expected ErrAccuracyMismatch, got %v", err)
+  }
+}
+
+func TestQuantileSketchMarshal(t *testing.T) {
+  sketch := NewQuantileSketch(0.01)
+  for i := -100; i <= 1000; i++ {
+    sketch.Add(float64(i) / 4.0)
+  }
+  data, err := sketch.MarshalBinary()
+  if err != nil {
+    t.Fatalf("Got error marshaling: %v", err)
+  }
+  restored := NewQuantileSketch(0.5)
+  if err := restored.UnmarshalBinary(data); err != nil {
+    t.Fatalf("Got error unmarshaling: %v", err)
+  }
+  if restored.Count() != sketch.Count() || restored.RelativeAccuracy() != 0.01 {
+    t.Errorf("Expected %v values with accuracy 0.01, got %v with %v", sketch.Count(), restored.Count(), restored.RelativeAccuracy())
+  }
+  for _, q := range []float64{0.0, 0.1, 0.5, 0.95, 1.0} {
+    if expected, actual := sketch.Quantile(q), restored.Quantile(q); expected != actual {
+      t.Errorf("For %v, expected %v, got %v", q, expected, actual)
+    }
+  }
+  if err := restored.UnmarshalBinary(data[:len(data) - 1]); err != ErrBadSketchData {
+    t.Errorf("Expected ErrBadSketchData, got %v", err)
+  }
+  if err := restored.UnmarshalBinary(nil); err != ErrBadSketchData {
+    t.Errorf("Expected ErrBadSketchData, got %v", err)
+  }
+}
+
+func TestQuantilesWithMultiConsume(t *testing.T) {
+  quantiles := NewQuantiles(0.01, intExtractor)
+  stats := NewStats(intExtractor)
+  errors := functional.MultiConsume(
+      xrange(1, 101), new(int), nil, quantiles, stats)
+  if errors[0] != nil || errors[1] != nil {
+    t.Errorf("Expected no errors, got %v", errors)
+  }
+  verifyQuantile(t, quantiles.Sketch(), 0.5, 50.0)
+  verifySummary(t, stats.Summary(), 100, 5050.0, 1.0, 100.0, 50.5)
+}
+
+func TestQuantilesError(t *testing.T) {
+  quantiles := NewQuantiles(0.01, intExtractor)
+  doConsume(t, quantiles, errorStream{otherError}, otherError)
+}
+
+func verifyQuantile(
+    t *testing.T, sketch *QuantileSketch, q float64, expected float64) {
+  actual := sketch.Quantile(q)
+  if math.Abs(actual - expected) > sketch.RelativeAccuracy() * math.Abs(expected) {
+    t.Errorf("For %v, expected %v, got %v", q, expected, actual)
+  }
+}
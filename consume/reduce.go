@@ -0,0 +1,61 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+)
+
+// Aggregator is a Consumer that computes a single result from the values
+// it consumes. Since Aggregators are Consumers, functional.CompositeConsumer
+// can run several of them over the same Stream in one pass.
+type Aggregator interface {
+  functional.Consumer
+  // Result returns the result of the last call to Consume.
+  Result() interface{}
+}
+
+// Reducer is an Aggregator that folds T values into an A value.
+type Reducer[A, T any] struct {
+  init A
+  acc A
+  f func(accPtr *A, valuePtr *T) error
+}
+
+// Reduce returns a Reducer of T values. The Consume method of the returned
+// Reducer starts with a copy of *initPtr and calls f once for each T value
+// it consumes. f updates the A value at accPtr in place using the T value at
+// valuePtr. Consume stops and returns the error if f or the Stream reports
+// an error.
+func Reduce[A, T any](
+    initPtr *A, f func(accPtr *A, valuePtr *T) error) *Reducer[A, T] {
+  return &Reducer[A, T]{init: *initPtr, acc: *initPtr, f: f}
+}
+
+// Consume folds the values of s, a Stream of T.
+func (r *Reducer[A, T]) Consume(s functional.Stream) (err error) {
+  r.acc = r.init
+  var value T
+  for err = s.Next(&value); err == nil; err = s.Next(&value) {
+    if err = r.f(&r.acc, &value); err != nil {
+      return
+    }
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+// Value returns the A value from the last call to Consume.
+func (r *Reducer[A, T]) Value() A {
+  return r.acc
+}
+
+// Result returns the A value from the last call to Consume as an interface{}.
+func (r *Reducer[A, T]) Result() interface{} {
+  return r.acc
+}
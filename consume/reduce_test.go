@@ -0,0 +1,80 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "errors"
+  "fmt"
+  "github.com/keep94/gofunctional3/functional"
+  "testing"
+)
+
+var (
+  tooBigError = errors.New("too big.")
+)
+
+func TestReduce(t *testing.T) {
+  zero := 0
+  sum := Reduce(&zero, func(acc *int, value *int) error {
+    *acc += *value
+    return nil
+  })
+  doConsume(t, sum, xrange(0, 5), nil)
+  if output := sum.Value(); output != 10 {
+    t.Errorf("Expected 10, got %v", output)
+  }
+  // Each call to Consume starts over.
+  doConsume(t, sum, xrange(0, 3), nil)
+  if output := sum.Result(); output != 3 {
+    t.Errorf("Expected 3, got %v", output)
+  }
+}
+
+func TestReduceErrors(t *testing.T) {
+  zero := 0
+  sum := Reduce(&zero, func(acc *int, value *int) error {
+    if *value > 2 {
+      return tooBigError
+    }
+    *acc += *value
+    return nil
+  })
+  doConsume(t, sum, xrange(0, 5), tooBigError)
+  if output := sum.Value(); output != 3 {
+    t.Errorf("Expected 3, got %v", output)
+  }
+  doConsume(t, sum, errorStream{otherError}, otherError)
+  if output := sum.Value(); output != 0 {
+    t.Errorf("Expected 0, got %v", output)
+  }
+}
+
+func ExampleReduce() {
+  zero := 0
+  count := Reduce(&zero, func(acc *int, value *int) error {
+    *acc++
+    return nil
+  })
+  sum := Reduce(&zero, func(acc *int, value *int) error {
+    *acc += *value
+    return nil
+  })
+  max := Reduce(&zero, func(acc *int, value *int) error {
+    if *value > *acc {
+      *acc = *value
+    }
+    return nil
+  })
+  functional.CompositeConsumer(new(int), nil, count, sum, max).Consume(
+      xrange(1, 5))
+  for _, a := range []Aggregator{count, sum, max} {
+    fmt.Println(a.Result())
+  }
+  // Output:
+  // 4
+  // 10
+  // 4
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+  "math/rand"
+  "reflect"
+)
+
+// Reservoir is an Aggregator that keeps a uniform random sample of the T
+// values it consumes without knowing ahead of time how many there are.
+type Reservoir struct {
+  sample []interface{}
+  count int64
+  temp interface{}
+  copier functional.Copier
+  rng *rand.Rand
+}
+
+// NewReservoir returns a new Reservoir that keeps a sample of k T values.
+// creater is a Creater of T that NewReservoir calls k + 1 times to allocate
+// storage. copier is a Copier of T; nil means regular assignment.
+// rng is the source of randomness; pass a rand.Rand with a fixed seed for
+// repeatable results. NewReservoir panics if k is less than 1.
+func NewReservoir(
+    k int,
+    creater functional.Creater,
+    copier functional.Copier,
+    rng *rand.Rand) *Reservoir {
+  if k < 1 {
+    panic("k must be at least 1.")
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  sample := make([]interface{}, k)
+  for i := range sample {
+    sample[i] = creater()
+  }
+  return &Reservoir{
+      sample: sample, temp: creater(), copier: copier, rng: rng}
+}
+
+// Consume samples the values of s, a Stream of T. Each call to Consume
+// starts a new sample. Consume stops and returns the error if s reports an
+// error.
+func (r *Reservoir) Consume(s functional.Stream) (err error) {
+  r.count = 0
+  k := int64(len(r.sample))
+  for {
+    if r.count < k {
+      err = s.Next(r.sample[r.count])
+    } else {
+      err = s.Next(r.temp)
+    }
+    if err != nil {
+      break
+    }
+    if r.count >= k {
+      if j := r.rng.Int63n(r.count + 1); j < k {
+        r.copier(r.temp, r.sample[j])
+      }
+    }
+    r.count++
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+// Values returns the sample from the last call to Consume as *T values in
+// no particular order. The sample has fewer than k values if the Stream did.
+// Returned slice and the T values it points to remain valid until the next
+// call to Consume.
+func (r *Reservoir) Values() []interface{} {
+  if r.count < int64(len(r.sample)) {
+    return r.sample[:r.count]
+  }
+  return r.sample
+}
+
+// Count returns how many values the last call to Consume read.
+func (r *Reservoir) Count() int64 {
+  return r.count
+}
+
+// Result returns the same thing as Values as an interface{}.
+func (r *Reservoir) Result() interface{} {
+  return r.Values()
+}
+
+func assignCopier(src, dest interface{}) {
+  reflect.Indirect(reflect.ValueOf(dest)).Set(
+      reflect.Indirect(reflect.ValueOf(src)))
+}
@@ -0,0 +1,85 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "math/rand"
+  "testing"
+)
+
+func TestReservoir(t *testing.T) {
+  r := NewReservoir(10, intCreater, nil, rand.New(rand.NewSource(1)))
+  doConsume(t, r, xrange(0, 1000), nil)
+  values := r.Values()
+  if len(values) != 10 || r.Count() != 1000 {
+    t.Fatalf("Expected 10 of 1000 values, got %v of %v", len(values), r.Count())
+  }
+  seen := make(map[int]bool)
+  for _, v := range values {
+    x := *v.(*int)
+    if x < 0 || x >= 1000 || seen[x] {
+      t.Errorf("Got bad or duplicate value %v", x)
+    }
+    seen[x] = true
+  }
+}
+
+func TestReservoirUniform(t *testing.T) {
+  rng := rand.New(rand.NewSource(3))
+  r := NewReservoir(2, intCreater, nil, rng)
+  counts := make([]int, 10)
+  for i := 0; i < 5000; i++ {
+    doConsume(t, r, xrange(0, 10), nil)
+    for _, v := range r.Values() {
+      counts[*v.(*int)]++
+    }
+  }
+  // Each value should be in the sample about 1000 times.
+  for i, c := range counts {
+    if c < 850 || c > 1150 {
+      t.Errorf("Value %v sampled %v times", i, c)
+    }
+  }
+}
+
+func TestReservoirRepeatable(t *testing.T) {
+  first := NewReservoir(5, intCreater, nil, rand.New(rand.NewSource(7)))
+  doConsume(t, first, xrange(0, 100), nil)
+  second := NewReservoir(5, intCreater, nil, rand.New(rand.NewSource(7)))
+  doConsume(t, second, xrange(0, 100), nil)
+  if output1, output2 := toInts(first.Values()), toInts(second.Values()); output1 != output2 {
+    t.Errorf("Expected same sample, got %v and %v", output1, output2)
+  }
+}
+
+func TestReservoirSmallStream(t *testing.T) {
+  r := NewReservoir(5, intCreater, nil, rand.New(rand.NewSource(1)))
+  doConsume(t, r, xrange(0, 3), nil)
+  if output := toInts(r.Values()); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  if output := toInts(r.Result().([]interface{})); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+}
+
+func TestReservoirError(t *testing.T) {
+  r := NewReservoir(5, intCreater, nil, rand.New(rand.NewSource(1)))
+  doConsume(t, r, xrange(0, 3), nil)
+  doConsume(t, r, errorStream{otherError}, otherError)
+  if output := len(r.Values()); output != 0 {
+    t.Errorf("Expected 0 values, got %v", output)
+  }
+}
+
+func toInts(values []interface{}) string {
+  result := make([]int, len(values))
+  for i := range values {
+    result[i] = *values[i].(*int)
+  }
+  return fmt.Sprintf("%v", result)
+}
@@ -0,0 +1,119 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+  "math"
+)
+
+// Summary holds descriptive statistics of a set of numbers.
+// Min, Max, and Mean are 0 if Count is 0.
+type Summary struct {
+  Count int64
+  Sum float64
+  Min float64
+  Max float64
+  Mean float64
+  // m2 is the sum of the squared differences from Mean.
+  m2 float64
+}
+
+// Add adds x to this instance using Welford's method.
+func (s *Summary) Add(x float64) {
+  if s.Count == 0 || x < s.Min {
+    s.Min = x
+  }
+  if s.Count == 0 || x > s.Max {
+    s.Max = x
+  }
+  s.Count++
+  s.Sum += x
+  delta := x - s.Mean
+  s.Mean += delta / float64(s.Count)
+  s.m2 += delta * (x - s.Mean)
+}
+
+// Merge returns the Summary of the numbers in both s and other. Use it to
+// combine Summaries computed for separate partitions of the same data.
+func (s Summary) Merge(other Summary) Summary {
+  if other.Count == 0 {
+    return s
+  }
+  if s.Count == 0 {
+    return other
+  }
+  count := s.Count + other.Count
+  delta := other.Mean - s.Mean
+  return Summary{
+      Count: count,
+      Sum: s.Sum + other.Sum,
+      Min: math.Min(s.Min, other.Min),
+      Max: math.Max(s.Max, other.Max),
+      Mean: s.Mean + delta * float64(other.Count) / float64(count),
+      m2: s.m2 + other.m2 + delta * delta * float64(s.Count) * float64(other.Count) / float64(count),
+  }
+}
+
+// Variance returns the population variance. It returns NaN if Count is 0.
+func (s Summary) Variance() float64 {
+  if s.Count == 0 {
+    return math.NaN()
+  }
+  return s.m2 / float64(s.Count)
+}
+
+// SampleVariance returns the sample variance. It returns NaN if Count is
+// less than 2.
+func (s Summary) SampleVariance() float64 {
+  if s.Count < 2 {
+    return math.NaN()
+  }
+  return s.m2 / float64(s.Count - 1)
+}
+
+// StdDev returns the population standard deviation. It returns NaN if
+// Count is 0.
+func (s Summary) StdDev() float64 {
+  return math.Sqrt(s.Variance())
+}
+
+// Stats is an Aggregator that computes a Summary of the T values it
+// consumes in a single pass.
+type Stats[T any] struct {
+  extractor func(ptr *T) float64
+  summary Summary
+}
+
+// NewStats returns a new Stats. extractor returns the number to summarize
+// from the T value at ptr.
+func NewStats[T any](extractor func(ptr *T) float64) *Stats[T] {
+  return &Stats[T]{extractor: extractor}
+}
+
+// Consume computes the Summary of stream, a Stream of T. Consume stops and
+// returns the error if stream reports an error.
+func (s *Stats[T]) Consume(stream functional.Stream) (err error) {
+  s.summary = Summary{}
+  var value T
+  for err = stream.Next(&value); err == nil; err = stream.Next(&value) {
+    s.summary.Add(s.extractor(&value))
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+// Summary returns the Summary from the last call to Consume.
+func (s *Stats[T]) Summary() Summary {
+  return s.summary
+}
+
+// Result returns the Summary from the last call to Consume as an interface{}.
+func (s *Stats[T]) Result() interface{} {
+  return s.summary
+}
@@ -0,0 +1,107 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+  "math"
+  "testing"
+)
+
+func TestStats(t *testing.T) {
+  stats := NewStats(intExtractor)
+  doConsume(t, stats, xrange(1, 5), nil)
+  summary := stats.Summary()
+  verifySummary(t, summary, 4, 10.0, 1.0, 4.0, 2.5)
+  verifyFloat(t, summary.Variance(), 1.25)
+  verifyFloat(t, summary.SampleVariance(), 5.0 / 3.0)
+  verifyFloat(t, summary.StdDev(), math.Sqrt(1.25))
+  if output := stats.Result().(Summary); output != summary {
+    t.Errorf("Expected %v, got %v", summary, output)
+  }
+}
+
+func TestStatsEmpty(t *testing.T) {
+  stats := NewStats(intExtractor)
+  doConsume(t, stats, xrange(0, 0), nil)
+  summary := stats.Summary()
+  verifySummary(t, summary, 0, 0.0, 0.0, 0.0, 0.0)
+  if !math.IsNaN(summary.Variance()) || !math.IsNaN(summary.SampleVariance()) {
+    t.Error("Expected NaN variances for empty summary.")
+  }
+}
+
+func TestStatsError(t *testing.T) {
+  stats := NewStats(intExtractor)
+  doConsume(t, stats, xrange(1, 5), nil)
+  doConsume(t, stats, errorStream{otherError}, otherError)
+  verifySummary(t, stats.Summary(), 0, 0.0, 0.0, 0.0, 0.0)
+}
+
+func TestStatsStable(t *testing.T) {
+  // A naive sum of squares loses all precision here.
+  var summary Summary
+  for _, x := range []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16} {
+    summary.Add(x)
+  }
+  verifyFloat(t, summary.SampleVariance(), 30.0)
+}
+
+func TestSummaryMerge(t *testing.T) {
+  whole := NewStats(intExtractor)
+  doConsume(t, whole, xrange(0, 10), nil)
+  first := NewStats(intExtractor)
+  doConsume(t, first, xrange(0, 3), nil)
+  second := NewStats(intExtractor)
+  doConsume(t, second, xrange(3, 10), nil)
+  merged := first.Summary().Merge(second.Summary())
+  expected := whole.Summary()
+  verifySummary(
+      t, merged, expected.Count, expected.Sum, expected.Min, expected.Max,
+      expected.Mean)
+  verifyFloat(t, merged.Variance(), expected.Variance())
+  if output := merged.Merge(Summary{}); output != merged {
+    t.Errorf("Expected %v, got %v", merged, output)
+  }
+  if output := (Summary{}).Merge(merged); output != merged {
+    t.Errorf("Expected %v, got %v", merged, output)
+  }
+}
+
+func TestStatsWithFilterConsumer(t *testing.T) {
+  stats := NewStats(intExtractor)
+  consumer := functional.FilterConsumer(
+      stats,
+      functional.NewFilterer(func(ptr interface{}) error {
+        if *ptr.(*int) % 2 == 1 {
+          return functional.Skipped
+        }
+        return nil
+      }))
+  doConsume(t, consumer, xrange(0, 7), nil)
+  verifySummary(t, stats.Summary(), 4, 12.0, 0.0, 6.0, 3.0)
+}
+
+func intExtractor(ptr *int) float64 {
+  return float64(*ptr)
+}
+
+func verifySummary(
+    t *testing.T,
+    s Summary,
+    count int64,
+    sum, min, max, mean float64) {
+  if s.Count != count || s.Sum != sum || s.Min != min || s.Max != max {
+    t.Errorf("Expected %v %v %v %v, got %v %v %v %v", count, sum, min, max, s.Count, s.Sum, s.Min, s.Max)
+  }
+  verifyFloat(t, s.Mean, mean)
+}
+
+func verifyFloat(t *testing.T, actual, expected float64) {
+  if math.Abs(actual - expected) > 1e-9 * math.Max(1.0, math.Abs(expected)) {
+    t.Errorf("Expected %v, got %v", expected, actual)
+  }
+}
@@ -0,0 +1,97 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+)
+
+// Ranked is an Aggregator that keeps the k T values that come first or last
+// in some order among those it consumes.
+type Ranked struct {
+  values []interface{}
+  idx int
+  // rank returns the Stream of ranked values.
+  rank func(s functional.Stream) functional.Stream
+}
+
+// TopK returns a Ranked that keeps the k values that come last according to
+// before. See functional.TopK for the meaning of the parameters.
+// TopK panics if k is less than 1.
+func TopK(
+    k int,
+    before func(lhs, rhs interface{}) bool,
+    creater functional.Creater,
+    copier functional.Copier) *Ranked {
+  return newRanked(k, creater, func(s functional.Stream) functional.Stream {
+    return functional.TopK(s, k, before, creater, copier)
+  })
+}
+
+// BottomK returns a Ranked that keeps the k values that come first
+// according to before. See functional.BottomK for the meaning of the
+// parameters. BottomK panics if k is less than 1.
+func BottomK(
+    k int,
+    before func(lhs, rhs interface{}) bool,
+    creater functional.Creater,
+    copier functional.Copier) *Ranked {
+  return newRanked(k, creater, func(s functional.Stream) functional.Stream {
+    return functional.BottomK(s, k, before, creater, copier)
+  })
+}
+
+// Consume ranks the values of s, a Stream of T.
+func (r *Ranked) Consume(s functional.Stream) (err error) {
+  // We don't close the ranked Stream as that would close s.
+  r.idx, err = readStreamIntoPtrs(r.rank(s), r.values)
+  if err == functional.Done {
+    err = nil
+  }
+  return
+}
+
+// Values returns the ranked values from the last call to Consume as *T
+// values, best first. There are fewer than k values if the Stream had
+// fewer. Returned slice and the T values it points to remain valid until
+// the next call to Consume.
+func (r *Ranked) Values() []interface{} {
+  return r.values[:r.idx]
+}
+
+// Result returns the same thing as Values as an interface{}.
+func (r *Ranked) Result() interface{} {
+  return r.Values()
+}
+
+func newRanked(
+    k int,
+    creater functional.Creater,
+    rank func(s functional.Stream) functional.Stream) *Ranked {
+  if k < 1 {
+    panic("k must be at least 1.")
+  }
+  values := make([]interface{}, k)
+  for i := range values {
+    values[i] = creater()
+  }
+  return &Ranked{values: values, rank: rank}
+}
+
+func readStreamIntoPtrs(
+    s functional.Stream, ptrs []interface{}) (numRead int, err error) {
+  l := len(ptrs)
+  for numRead = 0; numRead < l; numRead++ {
+    err = s.Next(ptrs[numRead])
+    if err != nil {
+      break
+    }
+  }
+  if numRead == l {
+    err = functional.Done
+  }
+  return
+}
@@ -0,0 +1,46 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+  "math/rand"
+  "testing"
+)
+
+func TestTopK(t *testing.T) {
+  values := rand.New(rand.NewSource(1)).Perm(100)
+  r := TopK(3, intBefore, intCreater, nil)
+  doConsume(t, r, functional.NewStreamFromValues(values, nil), nil)
+  if output := toInts(r.Values()); output != "[99 98 97]" {
+    t.Errorf("Expected [99 98 97] got %v", output)
+  }
+  doConsume(t, r, xrange(0, 2), nil)
+  if output := toInts(r.Result().([]interface{})); output != "[1 0]" {
+    t.Errorf("Expected [1 0] got %v", output)
+  }
+}
+
+func TestBottomK(t *testing.T) {
+  values := rand.New(rand.NewSource(1)).Perm(100)
+  r := BottomK(3, intBefore, intCreater, nil)
+  doConsume(t, r, functional.NewStreamFromValues(values, nil), nil)
+  if output := toInts(r.Values()); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+}
+
+func TestTopKError(t *testing.T) {
+  r := TopK(3, intBefore, intCreater, nil)
+  doConsume(t, r, errorStream{otherError}, otherError)
+  if output := len(r.Values()); output != 0 {
+    t.Errorf("Expected 0 values, got %v", output)
+  }
+}
+
+func intBefore(lhs, rhs interface{}) bool {
+  return *lhs.(*int) < *rhs.(*int)
+}
@@ -0,0 +1,85 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// FromChannel returns a Stream of T that emits the values received on ch.
+// Next reports each non-nil error received on errCh as it arrives. Once ch
+// is closed and no error is waiting on errCh, Next returns Done. errCh may
+// be nil if the sender never reports errors.
+// Calling Close on returned Stream does nothing. The goroutine sending on ch
+// is responsible for closing it.
+func FromChannel[T any](ch <-chan T, errCh <-chan error) Stream {
+  return &channelStream[T]{ch: ch, errCh: errCh}
+}
+
+// ToChannel returns a Consumer of T that sends each value it consumes to ch.
+// If done is closed, the Consume method stops early and returns nil. The
+// Consume method returns any error other than Done from the Stream it is
+// consuming. Consume always closes ch before returning, so the returned
+// Consumer can be used only once. done may be nil.
+func ToChannel[T any](ch chan<- T, done <-chan struct{}) Consumer {
+  return ConsumerFunc(func(s Stream) error {
+    defer close(ch)
+    var x T
+    for {
+      select {
+      case <-done:
+        return nil
+      default:
+      }
+      if err := s.Next(&x); err != nil {
+        if err == Done {
+          return nil
+        }
+        return err
+      }
+      select {
+      case ch <- x:
+      case <-done:
+        return nil
+      }
+    }
+  })
+}
+
+type channelStream[T any] struct {
+  ch <-chan T
+  errCh <-chan error
+  closeDoesNothing
+}
+
+func (s *channelStream[T]) Next(ptr interface{}) error {
+  for s.ch != nil {
+    select {
+    case v, ok := <-s.ch:
+      if !ok {
+        s.ch = nil
+        continue
+      }
+      *ptr.(*T) = v
+      return nil
+    case err, ok := <-s.errCh:
+      if !ok {
+        s.errCh = nil
+      } else if err != nil {
+        return err
+      }
+    }
+  }
+  for s.errCh != nil {
+    select {
+    case err, ok := <-s.errCh:
+      if !ok {
+        s.errCh = nil
+      } else if err != nil {
+        return err
+      }
+    default:
+      return Done
+    }
+  }
+  return Done
+}
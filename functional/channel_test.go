@@ -0,0 +1,133 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestFromChannel(t *testing.T) {
+  ch := make(chan int)
+  go func() {
+    for i := 0; i < 4; i++ {
+      ch <- i
+    }
+    close(ch)
+  }()
+  stream := FromChannel(ch, nil)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3]" {
+    t.Errorf("Expected [0 1 2 3] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestFromChannelError(t *testing.T) {
+  ch := make(chan int)
+  errCh := make(chan error)
+  go func() {
+    ch <- 1
+    errCh <- scanError
+    ch <- 2
+    close(errCh)
+    close(ch)
+  }()
+  stream := FromChannel(ch, errCh)
+  var x int
+  expected := []error{nil, scanError, nil, Done, Done}
+  for i := range expected {
+    if err := stream.Next(&x); err != expected[i] {
+      t.Errorf("Expected %v, got %v", expected[i], err)
+    }
+  }
+  if x != 2 {
+    t.Errorf("Expected 2, got %v", x)
+  }
+}
+
+func TestFromChannelErrorAfterClose(t *testing.T) {
+  ch := make(chan int)
+  errCh := make(chan error, 1)
+  close(ch)
+  errCh <- scanError
+  stream := FromChannel(ch, errCh)
+  if err := stream.Next(new(int)); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  if err := stream.Next(new(int)); err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+}
+
+func TestToChannel(t *testing.T) {
+  ch := make(chan int)
+  errCh := make(chan error, 1)
+  go func() {
+    errCh <- ToChannel(ch, nil).Consume(xrange(0, 3))
+  }()
+  var results []int
+  for x := range ch {
+    results = append(results, x)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  if err := <-errCh; err != nil {
+    t.Errorf("Expected nil, got %v", err)
+  }
+}
+
+func TestToChannelError(t *testing.T) {
+  ch := make(chan int, 10)
+  stream := Filter(All(equal(1), errFilterer), xrange(0, 3))
+  if err := ToChannel(ch, nil).Consume(stream); err != filterError {
+    t.Errorf("Expected filterError, got %v", err)
+  }
+  if _, ok := <-ch; ok {
+    t.Error("Expected channel to be closed.")
+  }
+}
+
+func TestToChannelDone(t *testing.T) {
+  ch := make(chan int)
+  done := make(chan struct{})
+  errCh := make(chan error, 1)
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  go func() {
+    errCh <- ToChannel(ch, done).Consume(s)
+  }()
+  var results []int
+  for x := range ch {
+    results = append(results, x)
+    if x == 2 {
+      close(done)
+      break
+    }
+  }
+  if err := <-errCh; err != nil {
+    t.Errorf("Expected nil, got %v", err)
+  }
+  if _, ok := <-ch; ok {
+    t.Error("Expected channel to be closed.")
+  }
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  verifyCloseCalled(t, s, false)
+}
+
+func TestChannelRoundTrip(t *testing.T) {
+  ch := make(chan int)
+  go ToChannel(ch, nil).Consume(xrange(5, 8))
+  stream := FromChannel(ch, nil)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[5 6 7]" {
+    t.Errorf("Expected [5 6 7] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
@@ -5,6 +5,11 @@
 
 package functional
 
+import (
+  "fmt"
+  "strings"
+)
+
 // A Consumer of T consumes the T values from a Stream of T.
 type Consumer interface {
   // Consume consumes values from Stream s.
@@ -21,11 +26,17 @@ func (f ConsumerFunc) Consume(s Stream) error {
 
 // CompositeConsumer returns a Consumer that sends values it consumes to each
 // one of consumers. The returned Consumer's Consume method reports an error if 
-// the Consume method in any of consumers reports an error.
+// the Consume method in any of consumers reports an error. When consumers has
+// more than one Consumer, this error is a ConsumerErrors value that holds
+// the error from each failing consumer, so use errors.Is or errors.As rather
+// than == to check for a particular error. CompositeConsumer never cancels
+// the remaining consumers when one fails; use CompositeConsumerWithOptions
+// with CancelOnError for that.
 // ptr is a *T where T values being consumed are temporarily held;
 // copier knows how to copy the values of type T being consumed
 // (can be nil if simple assignment should be used). If caller passes a slice
-// for consumers, no copy is made of it.
+// for consumers, no copy is made of it. If consumers has just one Consumer,
+// CompositeConsumer returns it as is, so errors are reported unwrapped.
 func CompositeConsumer(
     ptr interface{},
     copier Copier,
@@ -37,17 +48,48 @@ func CompositeConsumer(
       return consumers[0]
     default:
       return ConsumerFunc(func(s Stream) error {
-        errors := MultiConsume(s, ptr, copier, consumers...)
-        for _, e := range errors {
-          if e != nil {
-            return e
-          }
-        }
-        return nil
+        return newConsumerErrors(MultiConsume(s, ptr, copier, consumers...))
       })
   }
 }
 
+// ConsumerError is the error from one consumer of a composite Consumer.
+type ConsumerError struct {
+  // Index is the position of the failing consumer.
+  Index int
+  // Err is the error from the consumer's Consume method.
+  Err error
+}
+
+func (e *ConsumerError) Error() string {
+  return fmt.Sprintf("consumer %d: %v", e.Index, e.Err)
+}
+
+func (e *ConsumerError) Unwrap() error {
+  return e.Err
+}
+
+// ConsumerErrors holds the errors from the failing consumers of a composite
+// Consumer in the order of the consumers. errors.Is and errors.As look at
+// each error in it.
+type ConsumerErrors []*ConsumerError
+
+func (e ConsumerErrors) Error() string {
+  msgs := make([]string, len(e))
+  for i := range e {
+    msgs[i] = e[i].Error()
+  }
+  return "functional: " + strings.Join(msgs, "; ")
+}
+
+func (e ConsumerErrors) Unwrap() []error {
+  result := make([]error, len(e))
+  for i := range e {
+    result[i] = e[i]
+  }
+  return result
+}
+
 // FilterConsumer creates a new Consumer whose Consume method applies f to the
 // Stream before passing it onto c.
 func FilterConsumer(c Consumer, f Filterer) Consumer {
@@ -188,6 +230,21 @@ func (m *mapConsumer) Consume(s Stream) error {
   return m.consumer.Consume(Map(m.mapper, s, m.ptr))
 }
 
+// newConsumerErrors returns the non nil errors in errors as a ConsumerErrors
+// value or nil if there are none.
+func newConsumerErrors(errors []error) error {
+  var result ConsumerErrors
+  for i, e := range errors {
+    if e != nil {
+      result = append(result, &ConsumerError{Index: i, Err: e})
+    }
+  }
+  if result == nil {
+    return nil
+  }
+  return result
+}
+
 func asyncReturn(streams []splitStream, err error) bool {
   for i := range streams {
     if !streams[i].isClosed() {
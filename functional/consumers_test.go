@@ -143,8 +143,31 @@ func TestCompositeConsumerError(t *testing.T) {
       new(int),
       nil,
       ec,
+      oc,
       oc)
-  doConsume(t, consumer, Slice(Count(), 0, 5), consumerError)
+  err := consumer.Consume(Slice(Count(), 0, 5))
+  if !errors.Is(err, consumerError) {
+    t.Errorf("Expected consumerError, got %v", err)
+  }
+  var ce *ConsumerError
+  if !errors.As(err, &ce) || ce.Index != 1 {
+    t.Errorf("Expected ConsumerError for index 1, got %v", err)
+  }
+  errs, ok := err.(ConsumerErrors)
+  if !ok || len(errs) != 2 || errs[0].Index != 1 || errs[1].Index != 2 {
+    t.Errorf("Expected errors for consumers 1 and 2, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", ec.results); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] got %v", output)
+  }
+  // A single consumer is returned as is, so its error is not wrapped.
+  err = CompositeConsumer(new(int), nil, oc).Consume(Slice(Count(), 0, 5))
+  if err != consumerError {
+    t.Errorf("Expected consumerError, got %v", err)
+  }
+  if errors.As(err, &ce) {
+    t.Errorf("Expected unwrapped error, got %v", err)
+  }
 }
 
 func TestModifyConsumerStreamError(t *testing.T) {
@@ -0,0 +1,79 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "context"
+)
+
+// ContextEmitter is the Emitter that NewGeneratorContext passes to its
+// emitting function.
+type ContextEmitter interface {
+  Emitter
+
+  // Done returns the Done channel of the context passed to
+  // NewGeneratorContext. Emitting functions can select on it while waiting
+  // for values to emit.
+  Done() <-chan struct{}
+
+  // Err returns the Err of the context passed to NewGeneratorContext.
+  Err() error
+}
+
+// WithContext returns a Stream that emits the values of s until ctx is
+// cancelled. Once ctx is cancelled, Next returns ctx.Err() without calling
+// Next on s. If Next on s returns Done after ctx is cancelled, Next returns
+// ctx.Err() instead so that callers can tell cancellation apart from the
+// end of s.
+// Calling Close on returned Stream closes s.
+func WithContext(ctx context.Context, s Stream) Stream {
+  return &contextStream{Stream: s, ctx: ctx}
+}
+
+// NewGeneratorContext works like NewGenerator except that the returned Stream
+// behaves as if it were wrapped with WithContext and the emitting function
+// gets a ContextEmitter. When the emitting function sees ctx cancelled, it
+// should stop emitting values and call WaitForClose() before performing any
+// cleanup just as it would with NewGenerator. Caller must still call Close()
+// on returned Stream or else the goroutine operating the Stream will never
+// exit.
+func NewGeneratorContext(
+    ctx context.Context, f func(e ContextEmitter) error) Stream {
+  return WithContext(ctx, NewGenerator(func(e Emitter) error {
+    return f(contextEmitter{Emitter: e, ctx: ctx})
+  }))
+}
+
+type contextStream struct {
+  Stream
+  ctx context.Context
+}
+
+func (s *contextStream) Next(ptr interface{}) error {
+  if err := s.ctx.Err(); err != nil {
+    return err
+  }
+  err := s.Stream.Next(ptr)
+  if err == Done {
+    if cerr := s.ctx.Err(); cerr != nil {
+      return cerr
+    }
+  }
+  return err
+}
+
+type contextEmitter struct {
+  Emitter
+  ctx context.Context
+}
+
+func (e contextEmitter) Done() <-chan struct{} {
+  return e.ctx.Done()
+}
+
+func (e contextEmitter) Err() error {
+  return e.ctx.Err()
+}
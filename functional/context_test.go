@@ -0,0 +1,91 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "context"
+    "fmt"
+    "testing"
+)
+
+func TestWithContext(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+  s := &streamCloseChecker{xrange(0, 3), &simpleCloseChecker{}}
+  stream := WithContext(ctx, s)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s, true)
+}
+
+func TestWithContextCancel(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  stream := WithContext(ctx, s)
+  var x int
+  for i := 0; i < 3; i++ {
+    if err := stream.Next(&x); err != nil {
+      t.Fatalf("Expected nil, got %v", err)
+    }
+  }
+  cancel()
+  for i := 0; i < 2; i++ {
+    if err := stream.Next(&x); err != context.Canceled {
+      t.Errorf("Expected context.Canceled, got %v", err)
+    }
+  }
+  if x != 2 {
+    t.Errorf("Expected 2, got %v", x)
+  }
+  verifyCloseCalled(t, s, false)
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s, true)
+}
+
+func TestNewGeneratorContext(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  values := make(chan int)
+  var cleanedUp bool
+  stream := NewGeneratorContext(ctx, func(e ContextEmitter) error {
+    ptr, opened := e.EmitPtr()
+    for opened {
+      select {
+      case x := <-values:
+        *ptr.(*int) = x
+        ptr, opened = e.Return(nil)
+        continue
+      case <-e.Done():
+        e.Return(e.Err())
+      }
+      break
+    }
+    WaitForClose(e)
+    cleanedUp = true
+    return closeError
+  })
+  go func() {
+    values <- 3
+    values <- 5
+    cancel()
+  }()
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[3 5]" {
+    t.Errorf("Expected [3 5] got %v", output)
+  }
+  if err != context.Canceled {
+    t.Errorf("Expected context.Canceled, got %v", err)
+  }
+  if output := stream.Next(new(int)); output != context.Canceled {
+    t.Errorf("Expected context.Canceled, got %v", output)
+  }
+  assertBoolEqual(t, false, cleanedUp)
+  closeVerifyResult(t, stream, closeError)
+  assertBoolEqual(t, true, cleanedUp)
+}
@@ -0,0 +1,275 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "encoding/binary"
+  "fmt"
+  "hash/maphash"
+  "math"
+)
+
+// Dedupe returns a Stream that emits the values of s leaving out each value
+// equal to the one just before it, like the uniq command. equal returns
+// true if the T values at lhs and rhs are equal. lhs and rhs are *T.
+// creater is a Creater of T for remembering the previous value; copier is a
+// Copier of T where nil means regular assignment. Errors from s pass
+// through unchanged. Calling Close on returned Stream closes s.
+func Dedupe(
+    s Stream,
+    equal func(lhs, rhs interface{}) bool,
+    creater Creater,
+    copier Copier) Stream {
+  if copier == nil {
+    copier = assignCopier
+  }
+  previous := creater()
+  started := false
+  return Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if started && equal(previous, ptr) {
+          return Skipped
+        }
+        copier(ptr, previous)
+        started = true
+        return nil
+      }),
+      s)
+}
+
+// DedupeWithCounts works like Dedupe except that it also counts how many
+// times in a row each value appears, like the uniq -c command. The returned
+// Stream emits Tuples whose first field is an int holding the count and
+// whose second field is a T holding the value. Because a count is not known
+// until the next different value arrives, an error from s may come out
+// before the value ahead of it. creater is a Creater of T that
+// DedupeWithCounts calls twice. Calling Close on returned Stream closes s.
+func DedupeWithCounts(
+    s Stream,
+    equal func(lhs, rhs interface{}) bool,
+    creater Creater,
+    copier Copier) Stream {
+  if copier == nil {
+    copier = assignCopier
+  }
+  return &dedupeCountsStream{
+      Stream: s,
+      equal: equal,
+      copier: copier,
+      current: creater(),
+      next: creater()}
+}
+
+// Distinct returns a Stream that emits the values of s leaving out each value
+// whose key matches that of an earlier value. keyFunc returns the key of
+// the T value at ptr; keys must be comparable with ==. Distinct remembers
+// every key it sees. Errors from s pass through unchanged.
+// Calling Close on returned Stream closes s.
+func Distinct(s Stream, keyFunc func(ptr interface{}) interface{}) Stream {
+  seen := make(map[interface{}]struct{})
+  return Filter(
+      NewFilterer(func(ptr interface{}) error {
+        key := keyFunc(ptr)
+        if _, ok := seen[key]; ok {
+          return Skipped
+        }
+        seen[key] = struct{}{}
+        return nil
+      }),
+      s)
+}
+
+// DistinctWithLimit works like Distinct except that it remembers at most
+// maxKeys keys exactly. Once it sees more distinct keys than that, it moves
+// to a Bloom filter with a fixed size. Because a Bloom filter can report
+// that it has seen a key it hasn't, the returned Stream may then leave out
+// some values that Distinct would emit, but it never emits a repeat.
+// The Bloom filter is sized so that it reports such false positives at
+// falsePositiveRate after seeing expectedKeys keys.
+// DistinctWithLimit panics if maxKeys or expectedKeys is less than 1 or
+// falsePositiveRate is not between 0 and 1.
+// Calling Close on returned Stream closes s.
+func DistinctWithLimit(
+    s Stream,
+    keyFunc func(ptr interface{}) interface{},
+    maxKeys int,
+    expectedKeys int,
+    falsePositiveRate float64) Stream {
+  if maxKeys < 1 {
+    panic("maxKeys must be at least 1.")
+  }
+  if expectedKeys < 1 {
+    panic("expectedKeys must be at least 1.")
+  }
+  if !(falsePositiveRate > 0.0 && falsePositiveRate < 1.0) {
+    panic("falsePositiveRate must be between 0 and 1.")
+  }
+  seen := make(map[interface{}]struct{})
+  var bloom *bloomFilter
+  return Filter(
+      NewFilterer(func(ptr interface{}) error {
+        key := keyFunc(ptr)
+        if bloom != nil {
+          if bloom.addIfAbsent(key) {
+            return nil
+          }
+          return Skipped
+        }
+        if _, ok := seen[key]; ok {
+          return Skipped
+        }
+        seen[key] = struct{}{}
+        if len(seen) > maxKeys {
+          bloom = newBloomFilter(expectedKeys, falsePositiveRate)
+          for k := range seen {
+            bloom.addIfAbsent(k)
+          }
+          seen = nil
+        }
+        return nil
+      }),
+      s)
+}
+
+type dedupeCountsStream struct {
+  Stream
+  equal func(lhs, rhs interface{}) bool
+  copier Copier
+  // current is the value of the current run.
+  current interface{}
+  next interface{}
+  // count is the length of the current run.
+  count int
+  done bool
+}
+
+func (s *dedupeCountsStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  for {
+    err := s.Stream.Next(s.next)
+    if err == Done {
+      s.done = true
+      if s.count == 0 {
+        return Done
+      }
+      s.emit(ptr)
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+    if s.count > 0 && s.equal(s.current, s.next) {
+      s.count++
+      continue
+    }
+    emitting := s.count > 0
+    if emitting {
+      s.emit(ptr)
+    }
+    s.current, s.next = s.next, s.current
+    s.count = 1
+    if emitting {
+      return nil
+    }
+  }
+}
+
+func (s *dedupeCountsStream) emit(ptr interface{}) {
+  ptrs := ptr.(Tuple).Ptrs()
+  *ptrs[0].(*int) = s.count
+  s.copier(s.current, ptrs[1])
+}
+
+// bloomFilter is a Bloom filter of comparable keys.
+type bloomFilter struct {
+  bits []uint64
+  numBits uint64
+  numHashes int
+  seed1 maphash.Seed
+  seed2 maphash.Seed
+}
+
+func newBloomFilter(expectedKeys int, falsePositiveRate float64) *bloomFilter {
+  n := float64(expectedKeys)
+  numBits := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+  if numBits < 64 {
+    numBits = 64
+  }
+  numHashes := int(math.Round(float64(numBits) / n * math.Ln2))
+  if numHashes < 1 {
+    numHashes = 1
+  }
+  return &bloomFilter{
+      bits: make([]uint64, (numBits + 63) / 64),
+      numBits: numBits,
+      numHashes: numHashes,
+      seed1: maphash.MakeSeed(),
+      seed2: maphash.MakeSeed()}
+}
+
+// addIfAbsent adds key and returns true if key was not already present.
+func (b *bloomFilter) addIfAbsent(key interface{}) bool {
+  h1 := bloomHash(b.seed1, key)
+  h2 := bloomHash(b.seed2, key) | 1
+  added := false
+  for i := 0; i < b.numHashes; i++ {
+    bit := (h1 + uint64(i) * h2) % b.numBits
+    word, mask := bit / 64, uint64(1) << (bit % 64)
+    if b.bits[word] & mask == 0 {
+      b.bits[word] |= mask
+      added = true
+    }
+  }
+  return added
+}
+
+// bloomHash hashes key. Strings, integers, and byte arrays of common sizes
+// are hashed directly. Other keys fall back to hashing their Go syntax form
+// from fmt which is slow because it allocates and uses reflection. That
+// form is the same for equal keys except for floating point zeros of
+// different sign.
+func bloomHash(seed maphash.Seed, key interface{}) uint64 {
+  var n uint64
+  switch k := key.(type) {
+  case string:
+    return maphash.String(seed, k)
+  case [16]byte:
+    return maphash.Bytes(seed, k[:])
+  case [20]byte:
+    return maphash.Bytes(seed, k[:])
+  case [32]byte:
+    return maphash.Bytes(seed, k[:])
+  case int:
+    n = uint64(k)
+  case int8:
+    n = uint64(k)
+  case int16:
+    n = uint64(k)
+  case int32:
+    n = uint64(k)
+  case int64:
+    n = uint64(k)
+  case uint:
+    n = uint64(k)
+  case uint8:
+    n = uint64(k)
+  case uint16:
+    n = uint64(k)
+  case uint32:
+    n = uint64(k)
+  case uint64:
+    n = k
+  case uintptr:
+    n = uint64(k)
+  default:
+    return maphash.String(seed, fmt.Sprintf("%T:%#v", key, key))
+  }
+  var buf [8]byte
+  binary.LittleEndian.PutUint64(buf[:], n)
+  return maphash.Bytes(seed, buf[:])
+}
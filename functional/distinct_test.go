@@ -0,0 +1,182 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestDedupe(t *testing.T) {
+  s := Dedupe(
+      NewStreamFromValues([]int{1, 1, 2, 3, 3, 3, 1, 4, 4}, nil),
+      intEqual,
+      newInt,
+      nil)
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3 1 4]" {
+    t.Errorf("Expected [1 2 3 1 4] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestDedupeErrors(t *testing.T) {
+  s := Dedupe(
+      Filter(
+          NewFilterer(func(ptr interface{}) error {
+            if *ptr.(*int) == 2 {
+              return scanError
+            }
+            return nil
+          }),
+          NewStreamFromValues([]int{1, 1, 2, 1, 3}, nil)),
+      intEqual,
+      newInt,
+      nil)
+  var x int
+  expected := []error{nil, scanError, nil, Done}
+  expectedValues := []int{1, 2, 3, 3}
+  for i := range expected {
+    if err := s.Next(&x); err != expected[i] || x != expectedValues[i] {
+      t.Errorf("Expected %v %v, got %v %v", expectedValues[i], expected[i], x, err)
+    }
+  }
+}
+
+func TestDedupeWithCounts(t *testing.T) {
+  s := DedupeWithCounts(
+      NewStreamFromValues([]int{1, 1, 2, 3, 3, 3, 1}, nil),
+      intEqual,
+      newInt,
+      nil)
+  var results []string
+  var row intAndInt
+  err := s.Next(&row)
+  for ; err == nil; err = s.Next(&row) {
+    results = append(results, fmt.Sprintf("%dx%d", row.x, row.y))
+  }
+  if output := fmt.Sprintf("%v", results); output != "[2x1 1x2 3x3 1x1]" {
+    t.Errorf("Expected [2x1 1x2 3x3 1x1] got %v", output)
+  }
+  verifyDone(t, s, &row, err)
+}
+
+func TestDedupeWithCountsEmpty(t *testing.T) {
+  s := DedupeWithCounts(xrange(0, 0), intEqual, newInt, nil)
+  verifyDone(t, s, &intAndInt{}, s.Next(&intAndInt{}))
+}
+
+func TestDedupeWithCountsError(t *testing.T) {
+  s := DedupeWithCounts(
+      Filter(
+          NewFilterer(func(ptr interface{}) error {
+            if *ptr.(*int) == 2 {
+              return scanError
+            }
+            return nil
+          }),
+          NewStreamFromValues([]int{1, 2, 1, 3}, nil)),
+      intEqual,
+      newInt,
+      nil)
+  var row intAndInt
+  if err := s.Next(&row); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  if err := s.Next(&row); err != nil || row.x != 2 || row.y != 1 {
+    t.Errorf("Expected 2x1, got %dx%d %v", row.x, row.y, err)
+  }
+  if err := s.Next(&row); err != nil || row.x != 1 || row.y != 3 {
+    t.Errorf("Expected 1x3, got %dx%d %v", row.x, row.y, err)
+  }
+  verifyDone(t, s, &row, s.Next(&row))
+}
+
+func TestDistinct(t *testing.T) {
+  s := Distinct(
+      NewStreamFromValues([]int{3, 1, 3, 2, 1, 4, 2}, nil),
+      func(ptr interface{}) interface{} { return *ptr.(*int) })
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[3 1 2 4]" {
+    t.Errorf("Expected [3 1 2 4] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestDistinctWithLimit(t *testing.T) {
+  values := make([]int, 0, 2000)
+  for i := 0; i < 1000; i++ {
+    values = append(values, i, i / 2)
+  }
+  s := DistinctWithLimit(
+      NewStreamFromValues(values, nil),
+      func(ptr interface{}) interface{} { return *ptr.(*int) },
+      100,
+      1000,
+      0.001)
+  results, err := toIntArray(s)
+  verifyDone(t, s, new(int), err)
+  seen := make(map[int]bool)
+  for _, x := range results {
+    if seen[x] {
+      t.Fatalf("Got %v twice", x)
+    }
+    seen[x] = true
+  }
+  if len(results) < 990 {
+    t.Errorf("Expected about 1000 values, got %v", len(results))
+  }
+  for i := 0; i < 100; i++ {
+    if !seen[i] {
+      t.Errorf("Expected exact results for first keys, missing %v", i)
+    }
+  }
+}
+
+func TestBloomFilterKeyTypes(t *testing.T) {
+  type point struct {
+    x, y int
+  }
+  keys := []interface{}{
+      "a", "b", 3, int64(-3), uint8(7), [16]byte{1}, 2.5, point{1, 2}}
+  bloom := newBloomFilter(100, 0.001)
+  for _, k := range keys {
+    if !bloom.addIfAbsent(k) {
+      t.Errorf("Expected %v to be absent", k)
+    }
+  }
+  for _, k := range keys {
+    if bloom.addIfAbsent(k) {
+      t.Errorf("Expected %v to be present", k)
+    }
+  }
+  if !bloom.addIfAbsent(point{2, 1}) {
+    // Could be a false positive, but not at this rate with these keys.
+    t.Error("Expected {2 1} to be absent")
+  }
+}
+
+func TestDistinctClose(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 5), &simpleCloseChecker{closeError: closeError}}
+  closeVerifyResult(t, Distinct(s, func(ptr interface{}) interface{} { return *ptr.(*int) }), closeError)
+  verifyCloseCalled(t, s, true)
+  s = &streamCloseChecker{xrange(0, 5), &simpleCloseChecker{closeError: closeError}}
+  closeVerifyResult(t, DedupeWithCounts(s, intEqual, newInt, nil), closeError)
+  verifyCloseCalled(t, s, true)
+}
+
+func intEqual(lhs, rhs interface{}) bool {
+  return *lhs.(*int) == *rhs.(*int)
+}
+
+type intAndInt struct {
+  x int
+  y int
+}
+
+func (t *intAndInt) Ptrs() []interface{} {
+  return []interface{}{&t.x, &t.y}
+}
@@ -0,0 +1,40 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional_test
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional3/functional"
+)
+
+func ExampleGroupBy() {
+  s := functional.NewStreamFromPtrs(
+      []*DateCount{
+          {YMD(2013, 5, 24), 13},
+          {YMD(2013, 4, 1), 5},
+          {YMD(2013, 1, 1), 8},
+          {YMD(2012, 12, 31), 24},
+          {YMD(2012, 5, 26), 10}},
+      nil)
+  byYear := func(ptr interface{}) interface{} {
+    return ptr.(*DateCount).Date.Year()
+  }
+  groups := functional.GroupBy(
+      s, byYear, func() interface{} { return new(DateCount) }, nil)
+  defer groups.Close()
+  var g functional.Group
+  var dc DateCount
+  for err := groups.Next(&g); err == nil; err = groups.Next(&g) {
+    var sum int64
+    for err = g.Stream.Next(&dc); err == nil; err = g.Stream.Next(&dc) {
+      sum += dc.Count
+    }
+    fmt.Printf("%d: %d\n", g.Key, sum)
+  }
+  // Output:
+  // 2013: 26
+  // 2012: 34
+}
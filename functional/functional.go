@@ -233,17 +233,7 @@ func Merge(
   if len(streams) == 1 {
     return streams[0]
   }
-  if copier == nil {
-    copier = assignCopier
-  }
-  h := &streamHeapWithLess{
-      streamHeap: make(streamHeap, len(streams)), before: before}
-  for i := range streams {
-    h.streamHeap[i] = &item{stream: streams[i], current: creater()}
-    h.streamHeap[i].pop()
-  }
-  heap.Init(h)
-  return &mergeStream{orig: streams, sh: h, copier: copier}
+  return merge(creater, copier, before, &MergeOptions{}, false, streams)
 }
 
 // Concat concatenates multiple Streams into one.
@@ -556,14 +546,7 @@ func (c *concatStream) Next(ptr interface{}) error {
 }
 
 func (c *concatStream) Close() error {
-  var result error
-  for i := range c.s {
-    err := c.s[i].Close()
-    if result == nil {
-      result = err
-    }
-  }
-  return result
+  return closeAll(c.s)
 }
 
 type plainStream struct {
@@ -674,12 +657,32 @@ type item struct {
   stream Stream
   current interface{}
   e error
+  // index, previous, and position are used only when checking order.
+  // index is the index of stream among the merged streams; position is
+  // the number of values popped from stream.
+  index int
+  previous interface{}
+  position int64
 }
 
 func (i *item) pop() {
   i.e = i.stream.Next(i.current)
 }
 
+// checkedPop works like pop, but sets e to an *ErrOutOfOrder if the new
+// current value comes before the previous one. It leaves current and
+// previous alone if stream reports an error.
+func (i *item) checkedPop(before func(lhs, rhs interface{}) bool) {
+  if i.e = i.stream.Next(i.previous); i.e != nil {
+    return
+  }
+  i.current, i.previous = i.previous, i.current
+  if i.position > 0 && before(i.current, i.previous) {
+    i.e = &ErrOutOfOrder{Input: i.index, Position: i.position}
+  }
+  i.position++
+}
+
 type streamHeap []*item
 
 func (sh streamHeap) Len() int {
@@ -704,6 +707,8 @@ func (sh *streamHeap) Pop() interface{} {
 type streamHeapWithLess struct {
   streamHeap
   before func(lhs, rhs interface{}) bool
+  // stable, if true, breaks ties by stream index.
+  stable bool
 }
 
 func (sh *streamHeapWithLess) Less(i, j int) bool {
@@ -713,13 +718,19 @@ func (sh *streamHeapWithLess) Less(i, j int) bool {
   if sh.streamHeap[j].e != nil {
     return false
   }
-  return sh.before(sh.streamHeap[i].current, sh.streamHeap[j].current)
+  lhs, rhs := sh.streamHeap[i], sh.streamHeap[j]
+  if sh.before(lhs.current, rhs.current) {
+    return true
+  }
+  return sh.stable && lhs.index < rhs.index && !sh.before(rhs.current, lhs.current)
 }
 
 type mergeStream struct {
   orig []Stream
   sh *streamHeapWithLess
   copier Copier
+  options MergeOptions
+  withSource bool
 }
 
 func (s *mergeStream) Next(ptr interface{}) error {
@@ -729,16 +740,35 @@ func (s *mergeStream) Next(ptr interface{}) error {
       continue
     }
     if aitem.e != nil {
-      return aitem.e
+      err := aitem.e
+      if s.options.ContinueOnError {
+        s.pop(aitem)
+        heap.Push(s.sh, aitem)
+      }
+      return err
     }
-    s.copier(aitem.current, ptr)
-    aitem.pop()
+    if s.withSource {
+      ptrs := ptr.(Tuple).Ptrs()
+      *ptrs[0].(*int) = aitem.index
+      s.copier(aitem.current, ptrs[1])
+    } else {
+      s.copier(aitem.current, ptr)
+    }
+    s.pop(aitem)
     heap.Push(s.sh, aitem)
     return nil
   }
   return Done
 }
       
+func (s *mergeStream) pop(aitem *item) {
+  if s.options.CheckOrder {
+    aitem.checkedPop(s.sh.before)
+  } else {
+    aitem.pop()
+  }
+}
+
 func (s *mergeStream) Close() error {
   var result error
   for _, stream := range s.orig {
@@ -749,6 +779,49 @@ func (s *mergeStream) Close() error {
   return result
 }
 
+func merge(
+    creater Creater,
+    copier Copier,
+    before func(lhs, rhs interface{}) bool,
+    options *MergeOptions,
+    withSource bool,
+    streams []Stream) Stream {
+  if copier == nil {
+    copier = assignCopier
+  }
+  h := &streamHeapWithLess{
+      streamHeap: make(streamHeap, len(streams)),
+      before: before,
+      stable: withSource}
+  result := &mergeStream{
+      orig: streams,
+      sh: h,
+      copier: copier,
+      options: *options,
+      withSource: withSource}
+  for i := range streams {
+    h.streamHeap[i] = &item{stream: streams[i], current: creater(), index: i}
+    if options.CheckOrder {
+      h.streamHeap[i].previous = creater()
+    }
+    result.pop(h.streamHeap[i])
+  }
+  heap.Init(h)
+  return result
+}
+
+// closeAll closes each of streams returning the first error encountered.
+func closeAll(streams []Stream) error {
+  var result error
+  for i := range streams {
+    err := streams[i].Close()
+    if result == nil {
+      result = err
+    }
+  }
+  return result
+}
+
 type closeDoesNothing struct {
 }
 
@@ -0,0 +1,110 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// Group is the type of value that the Stream GroupBy returns emits.
+type Group struct {
+  // Key is the key that every value in the group shares.
+  Key interface{}
+  // Stream emits the values in the group.
+  Stream Stream
+}
+
+// GroupBy returns a Stream of Group that emits one Group for each run of
+// consecutive values in s, a Stream of T, that share the same key. keyFunc
+// returns the key of the T value ptr points to. Keys are compared with ==.
+// The Stream in each emitted Group is lazy: it reads from s only as caller
+// calls Next on it, and reports any error from s. It ends once the
+// key changes and remains usable only until the next call to Next on the
+// returned Stream, which skips any values left in the current Group.
+// Errors from s while skipping values are reported by the returned Stream.
+// creater is a Creater of T that GroupBy calls once to allocate storage for
+// reading ahead. copier is a Copier of T; nil means regular assignment.
+// Calling Close on the Stream of a Group does nothing.
+// Calling Close on returned Stream closes s.
+func GroupBy(
+    s Stream,
+    keyFunc func(ptr interface{}) interface{},
+    creater Creater,
+    copier Copier) Stream {
+  if copier == nil {
+    copier = assignCopier
+  }
+  return &groupByStream{
+      Stream: s,
+      keyFunc: keyFunc,
+      copier: copier,
+      lookAhead: creater()}
+}
+
+type groupByStream struct {
+  Stream
+  keyFunc func(ptr interface{}) interface{}
+  copier Copier
+  // lookAhead holds the value read ahead from Stream if hasLookAhead is true.
+  lookAhead interface{}
+  lookAheadKey interface{}
+  hasLookAhead bool
+  // key is the key of the current group
+  key interface{}
+  // gen is incremented with each new group; 0 means no group emitted yet.
+  gen int
+  done bool
+}
+
+func (s *groupByStream) Next(ptr interface{}) error {
+  for !s.hasLookAhead || (s.gen > 0 && s.lookAheadKey == s.key) {
+    if err := s.readAhead(); err != nil {
+      return err
+    }
+  }
+  s.key = s.lookAheadKey
+  s.gen++
+  *ptr.(*Group) = Group{Key: s.key, Stream: &groupStream{parent: s, gen: s.gen}}
+  return nil
+}
+
+// readAhead reads the next value from the underlying Stream into lookAhead.
+func (s *groupByStream) readAhead() error {
+  if s.done {
+    return Done
+  }
+  s.hasLookAhead = false
+  err := s.Stream.Next(s.lookAhead)
+  if err == Done {
+    s.done = true
+  }
+  if err != nil {
+    return err
+  }
+  s.lookAheadKey = s.keyFunc(s.lookAhead)
+  s.hasLookAhead = true
+  return nil
+}
+
+type groupStream struct {
+  parent *groupByStream
+  gen int
+  closeDoesNothing
+}
+
+func (s *groupStream) Next(ptr interface{}) error {
+  p := s.parent
+  if p.gen != s.gen {
+    return Done
+  }
+  if !p.hasLookAhead {
+    if err := p.readAhead(); err != nil {
+      return err
+    }
+  }
+  if p.lookAheadKey != p.key {
+    return Done
+  }
+  p.copier(p.lookAhead, ptr)
+  p.hasLookAhead = false
+  return nil
+}
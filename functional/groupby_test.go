@@ -0,0 +1,116 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestGroupBy(t *testing.T) {
+  s := NewStreamFromValues([]int{1, 3, 12, 15, 17, 21, 35, 36}, nil)
+  stream := GroupBy(s, tens, newInt, nil)
+  var results []string
+  var g Group
+  err := stream.Next(&g)
+  for ; err == nil; err = stream.Next(&g) {
+    values, gerr := toIntArray(g.Stream)
+    if gerr != Done {
+      t.Errorf("Expected Done, got %v", gerr)
+    }
+    results = append(results, fmt.Sprintf("%v:%v", g.Key, values))
+  }
+  if output := fmt.Sprintf("%v", results); output != "[0:[1 3] 1:[12 15 17] 2:[21] 3:[35 36]]" {
+    t.Errorf("Expected [0:[1 3] 1:[12 15 17] 2:[21] 3:[35 36]] got %v", output)
+  }
+  verifyDone(t, stream, new(Group), err)
+}
+
+func TestGroupBySkipsUnreadValues(t *testing.T) {
+  s := NewStreamFromValues([]int{1, 3, 5, 12, 15, 21, 35, 36}, nil)
+  stream := GroupBy(s, tens, newInt, nil)
+  var results []string
+  var g Group
+  var x int
+  err := stream.Next(&g)
+  for i := 0; err == nil; i++ {
+    // Read only the first i values of the ith group
+    var values []int
+    for j := 0; j < i && g.Stream.Next(&x) == nil; j++ {
+      values = append(values, x)
+    }
+    results = append(results, fmt.Sprintf("%v:%v", g.Key, values))
+    oldGroupStream := g.Stream
+    err = stream.Next(&g)
+    if output := oldGroupStream.Next(&x); output != Done {
+      t.Errorf("Expected old group Stream to return Done, got %v", output)
+    }
+  }
+  if output := fmt.Sprintf("%v", results); output != "[0:[] 1:[12] 2:[21] 3:[35 36]]" {
+    t.Errorf("Expected [0:[] 1:[12] 2:[21] 3:[35 36]] got %v", output)
+  }
+  verifyDone(t, stream, new(Group), err)
+}
+
+func TestGroupByEmpty(t *testing.T) {
+  stream := GroupBy(NilStream(), tens, newInt, nil)
+  var g Group
+  verifyDone(t, stream, &g, stream.Next(&g))
+}
+
+func TestGroupByErrors(t *testing.T) {
+  s := Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if x := *ptr.(*int); x == 3 || x == 13 {
+          return scanError
+        }
+        return nil
+      }),
+      NewStreamFromValues([]int{1, 3, 5, 12, 13, 15, 21}, nil))
+  stream := GroupBy(s, tens, newInt, nil)
+  var g Group
+  var x int
+  if err := stream.Next(&g); err != nil {
+    t.Fatalf("Expected nil, got %v", err)
+  }
+  expected := []error{nil, scanError, nil, Done}
+  for i := range expected {
+    if err := g.Stream.Next(&x); err != expected[i] {
+      t.Errorf("Expected %v, got %v", expected[i], err)
+    }
+  }
+  if err := stream.Next(&g); err != nil {
+    t.Fatalf("Expected nil, got %v", err)
+  }
+  // Skipping the rest of the second group reports the error from s.
+  if err := stream.Next(&g); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  if err := stream.Next(&g); err != nil {
+    t.Fatalf("Expected nil, got %v", err)
+  }
+  values, err := toIntArray(g.Stream)
+  if output := fmt.Sprintf("%v:%v", g.Key, values); output != "2:[21]" {
+    t.Errorf("Expected 2:[21] got %v", output)
+  }
+  verifyDone(t, g.Stream, new(int), err)
+  verifyDone(t, stream, &g, stream.Next(&g))
+}
+
+func TestGroupByClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{closeError: closeError}}
+  stream := GroupBy(s, tens, newInt, nil)
+  var g Group
+  stream.Next(&g)
+  closeVerifyResult(t, g.Stream, nil)
+  verifyCloseCalled(t, s, false)
+  closeVerifyResult(t, stream, closeError)
+  verifyCloseCalled(t, s, true)
+}
+
+func tens(ptr interface{}) interface{} {
+  return *ptr.(*int) / 10
+}
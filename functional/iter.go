@@ -0,0 +1,105 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "iter"
+)
+
+// Values returns the values of s, a Stream of T, as a sequence for use
+// with range. Each value s emits is yielded with a nil error; any error
+// other than Done that s reports is yielded with the zero value of T.
+// The sequence ends when s returns Done. Values takes ownership of s:
+// Close is called on s when the loop finishes, including when it exits
+// early or its body panics. If Close fails after s returned Done, its error
+// is yielded as a final element; if the loop exits early, the error from
+// Close is dropped. The returned sequence may be ranged over only once.
+func Values[T any](s Stream) iter.Seq2[T, error] {
+  return func(yield func(T, error) bool) {
+    var zero T
+    var x T
+    // finished is true if s returned Done so that the error from Close
+    // can be yielded.
+    var finished bool
+    defer func() {
+      if err := s.Close(); err != nil && finished {
+        yield(zero, err)
+      }
+    }()
+    for err := s.Next(&x); err != Done; err = s.Next(&x) {
+      var ok bool
+      if err == nil {
+        ok = yield(x, nil)
+      } else {
+        ok = yield(zero, err)
+      }
+      if !ok {
+        return
+      }
+    }
+    finished = true
+  }
+}
+
+// FromSeq converts seq into a Stream of T. Calling Close on returned Stream
+// calls the stop function from iter.Pull which lets seq run any deferred
+// cleanup. Caller must call Close on the returned Stream or else the
+// goroutine running seq may never exit.
+func FromSeq[T any](seq iter.Seq[T]) Stream {
+  next, stop := iter.Pull(seq)
+  return &seqStream[T]{next: next, stop: stop}
+}
+
+// FromSeq2 converts seq into a Stream of T. Next reports each non-nil error
+// that seq yields instead of emitting the value paired with it. Calling
+// Close on returned Stream calls the stop function from iter.Pull which lets
+// seq run any deferred cleanup. Caller must call Close on the returned
+// Stream or else the goroutine running seq may never exit.
+func FromSeq2[T any](seq iter.Seq2[T, error]) Stream {
+  next, stop := iter.Pull2(seq)
+  return &seq2Stream[T]{next: next, stop: stop}
+}
+
+type seqStream[T any] struct {
+  next func() (T, bool)
+  stop func()
+}
+
+func (s *seqStream[T]) Next(ptr interface{}) error {
+  v, ok := s.next()
+  if !ok {
+    return Done
+  }
+  *ptr.(*T) = v
+  return nil
+}
+
+func (s *seqStream[T]) Close() error {
+  s.stop()
+  return nil
+}
+
+type seq2Stream[T any] struct {
+  next func() (T, error, bool)
+  stop func()
+}
+
+func (s *seq2Stream[T]) Next(ptr interface{}) error {
+  v, err, ok := s.next()
+  if !ok {
+    return Done
+  }
+  if err != nil {
+    return err
+  }
+  *ptr.(*T) = v
+  return nil
+}
+
+func (s *seq2Stream[T]) Close() error {
+  s.stop()
+  return nil
+}
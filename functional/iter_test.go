@@ -0,0 +1,138 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "iter"
+    "slices"
+    "testing"
+)
+
+func TestValues(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 4), &simpleCloseChecker{}}
+  var results []int
+  for x, err := range Values[int](s) {
+    if err != nil {
+      t.Fatalf("Expected no error, got %v", err)
+    }
+    results = append(results, x)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3]" {
+    t.Errorf("Expected [0 1 2 3] got %v", output)
+  }
+  verifyCloseCalled(t, s, true)
+}
+
+func TestValuesEarlyExit(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  var results []int
+  for x, _ := range Values[int](s) {
+    if x == 3 {
+      break
+    }
+    results = append(results, x)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  verifyCloseCalled(t, s, true)
+}
+
+func TestValuesPanic(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  func() {
+    defer func() {
+      if recover() == nil {
+        t.Error("Expected panic")
+      }
+    }()
+    for x, _ := range Values[int](s) {
+      if x == 2 {
+        panic("loop body")
+      }
+    }
+  }()
+  verifyCloseCalled(t, s, true)
+}
+
+func TestValuesErrors(t *testing.T) {
+  s := &streamCloseChecker{
+      Filter(All(equal(1), errFilterer), xrange(0, 3)),
+      &simpleCloseChecker{closeError: closeError}}
+  var errs []error
+  for _, err := range Values[int](s) {
+    errs = append(errs, err)
+  }
+  if len(errs) != 2 || errs[0] != filterError || errs[1] != closeError {
+    t.Errorf("Expected [filterError closeError], got %v", errs)
+  }
+}
+
+func TestFromSeq(t *testing.T) {
+  stream := FromSeq(slices.Values([]int{3, 1, 4}))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[3 1 4]" {
+    t.Errorf("Expected [3 1 4] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestFromSeqClose(t *testing.T) {
+  var cleanedUp bool
+  seq := func(yield func(int) bool) {
+    defer func() { cleanedUp = true }()
+    for i := 0; yield(i); i++ {
+    }
+  }
+  stream := Slice(FromSeq(iter.Seq[int](seq)), 0, 2)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1]" {
+    t.Errorf("Expected [0 1] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  assertBoolEqual(t, false, cleanedUp)
+  closeVerifyResult(t, stream, nil)
+  assertBoolEqual(t, true, cleanedUp)
+}
+
+func TestFromSeq2(t *testing.T) {
+  seq := func(yield func(int, error) bool) {
+    if !yield(1, nil) {
+      return
+    }
+    if !yield(0, scanError) {
+      return
+    }
+    yield(2, nil)
+  }
+  stream := FromSeq2(iter.Seq2[int, error](seq))
+  var x int
+  expected := []error{nil, scanError, nil, Done, Done}
+  for i := range expected {
+    if err := stream.Next(&x); err != expected[i] {
+      t.Errorf("Expected %v, got %v", expected[i], err)
+    }
+  }
+  if x != 2 {
+    t.Errorf("Expected 2, got %v", x)
+  }
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestValuesFromSeqRoundTrip(t *testing.T) {
+  var results []int
+  for x, err := range Values[int](FromSeq(slices.Values([]int{2, 7}))) {
+    if err != nil {
+      t.Fatalf("Expected no error, got %v", err)
+    }
+    results = append(results, x)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[2 7]" {
+    t.Errorf("Expected [2 7] got %v", output)
+  }
+}
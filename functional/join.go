@@ -0,0 +1,217 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// JoinKind says which rows a join emits.
+type JoinKind int
+
+const (
+  // InnerJoin emits only matching rows.
+  InnerJoin JoinKind = iota
+  // LeftJoin also emits left rows that match no right row.
+  LeftJoin
+  // RightJoin also emits right rows that match no left row.
+  RightJoin
+  // FullJoin also emits left and right rows that match nothing.
+  FullJoin
+  // AntiJoin emits only the left rows that match no right row.
+  AntiJoin
+)
+
+// Pair is the Tuple that joins emit. Left points to the left row, and Right
+// points to the right row. In an outer join, the missing side is nil.
+type Pair struct {
+  Left interface{}
+  Right interface{}
+}
+
+// Ptrs returns pointers to Left and Right.
+func (p *Pair) Ptrs() []interface{} {
+  return []interface{}{&p.Left, &p.Right}
+}
+
+// MergeJoin joins left, a Stream of T, with right, a Stream of U, where both
+// are in order by the same key. compare returns a negative number, zero, or
+// a positive number if the key of the T value at l is less than, equal to,
+// or greater than the key of the U value at r. The returned Stream emits
+// Pairs in key order: each left row is paired with every right row with the
+// same key, and kind, which may be any JoinKind, says what to do with rows
+// that match nothing. With AntiJoin, the returned Stream instead emits only
+// the left rows that match no right row, with Right set to nil. The Left
+// and Right fields of emitted Pairs are *T and *U values that remain valid
+// until the next call to Next. MergeJoin holds in memory only the right rows
+// that share the current key. leftCreater is a Creater of T; rightCreater
+// is a Creater of U. Errors from left and right pass through.
+// Calling Close on returned Stream closes left and right.
+func MergeJoin(
+    left Stream,
+    right Stream,
+    compare func(l, r interface{}) int,
+    leftCreater Creater,
+    rightCreater Creater,
+    kind JoinKind) Stream {
+  result := &mergeJoinStream{
+      left: left,
+      right: right,
+      compare: compare,
+      rightCreater: rightCreater,
+      leftOuter: kind == LeftJoin || kind == FullJoin || kind == AntiJoin,
+      anti: kind == AntiJoin,
+      rightOuter: kind == RightJoin || kind == FullJoin,
+      lcur: leftCreater(),
+      rcur: rightCreater()}
+  result.advanceLeft()
+  result.advanceRight()
+  return result
+}
+
+type mergeJoinStream struct {
+  left Stream
+  right Stream
+  compare func(l, r interface{}) int
+  rightCreater Creater
+  leftOuter bool
+  rightOuter bool
+  anti bool
+  lcur interface{}
+  le error
+  rcur interface{}
+  re error
+  // run holds the right rows with the key of the current left row.
+  run []interface{}
+  runIdx int
+  // building is true while reading the right rows into run.
+  building bool
+  // inRun is true while pairing left rows with run.
+  inRun bool
+  // free holds storage for right rows not in use.
+  free []interface{}
+  // pendingLeft and pendingRight are true if a side has to advance
+  // before Next continues. Advancing right away would overwrite a row
+  // that caller still has.
+  pendingLeft bool
+  pendingRight bool
+}
+
+func (s *mergeJoinStream) Next(ptr interface{}) error {
+  if s.pendingLeft {
+    s.pendingLeft = false
+    s.advanceLeft()
+  }
+  if s.pendingRight {
+    s.pendingRight = false
+    s.advanceRight()
+  }
+  for {
+    if s.building {
+      if s.re == nil && s.compare(s.lcur, s.rcur) == 0 {
+        s.run = append(s.run, s.rcur)
+        s.rcur = s.newRightRow()
+        s.advanceRight()
+        continue
+      }
+      if s.re != nil && s.re != Done {
+        err := s.re
+        s.advanceRight()
+        return err
+      }
+      s.building = false
+      s.inRun = true
+      s.runIdx = 0
+    }
+    if s.inRun {
+      if s.le != nil && s.le != Done {
+        err := s.le
+        s.advanceLeft()
+        return err
+      }
+      if s.le == nil && s.runIdx < len(s.run) {
+        emitPair(ptr, s.lcur, s.run[s.runIdx])
+        s.runIdx++
+        if s.runIdx == len(s.run) {
+          s.pendingLeft = true
+        }
+        return nil
+      }
+      if s.le == nil && s.compare(s.lcur, s.run[0]) == 0 {
+        s.runIdx = 0
+        continue
+      }
+      s.free = append(s.free, s.run...)
+      s.run = s.run[:0]
+      s.inRun = false
+    }
+    if s.le != nil && s.le != Done {
+      err := s.le
+      s.advanceLeft()
+      return err
+    }
+    if s.re != nil && s.re != Done {
+      err := s.re
+      s.advanceRight()
+      return err
+    }
+    if s.le == Done && s.re == Done {
+      return Done
+    }
+    if s.re == Done || (s.le == nil && s.compare(s.lcur, s.rcur) < 0) {
+      if s.leftOuter {
+        emitPair(ptr, s.lcur, nil)
+        s.pendingLeft = true
+        return nil
+      }
+      if s.re == Done {
+        return Done
+      }
+      s.advanceLeft()
+      continue
+    }
+    if s.le == Done || s.compare(s.lcur, s.rcur) > 0 {
+      if s.rightOuter {
+        emitPair(ptr, nil, s.rcur)
+        s.pendingRight = true
+        return nil
+      }
+      if s.le == Done {
+        return Done
+      }
+      s.advanceRight()
+      continue
+    }
+    if s.anti {
+      s.advanceLeft()
+      continue
+    }
+    s.building = true
+  }
+}
+
+func (s *mergeJoinStream) Close() error {
+  return closeAll([]Stream{s.left, s.right})
+}
+
+func (s *mergeJoinStream) advanceLeft() {
+  s.le = s.left.Next(s.lcur)
+}
+
+func (s *mergeJoinStream) advanceRight() {
+  s.re = s.right.Next(s.rcur)
+}
+
+func (s *mergeJoinStream) newRightRow() interface{} {
+  if l := len(s.free); l > 0 {
+    result := s.free[l - 1]
+    s.free = s.free[:l - 1]
+    return result
+  }
+  return s.rightCreater()
+}
+
+func emitPair(ptr interface{}, l, r interface{}) {
+  p := ptr.(*Pair)
+  p.Left = l
+  p.Right = r
+}
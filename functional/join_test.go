@@ -0,0 +1,110 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestMergeJoin(t *testing.T) {
+  left := []int{1, 2, 2, 4, 6, 6}
+  right := []int{2, 2, 3, 4, 4, 6, 7}
+  expected := map[JoinKind]string{
+      InnerJoin: "[2:2 2:2 2:2 2:2 4:4 4:4 6:6 6:6]",
+      LeftJoin: "[1:- 2:2 2:2 2:2 2:2 4:4 4:4 6:6 6:6]",
+      RightJoin: "[2:2 2:2 2:2 2:2 -:3 4:4 4:4 6:6 6:6 -:7]",
+      FullJoin: "[1:- 2:2 2:2 2:2 2:2 -:3 4:4 4:4 6:6 6:6 -:7]",
+      AntiJoin: "[1:-]",
+  }
+  for kind, want := range expected {
+    s := MergeJoin(
+        NewStreamFromValues(left, nil),
+        NewStreamFromValues(right, nil),
+        intCompare,
+        newInt,
+        newInt,
+        kind)
+    results, err := joinToStrings(s)
+    if output := fmt.Sprintf("%v", results); output != want {
+      t.Errorf("Kind %d: Expected %v got %v", kind, want, output)
+    }
+    verifyDone(t, s, new(Pair), err)
+  }
+}
+
+func TestMergeJoinEmpty(t *testing.T) {
+  s := MergeJoin(xrange(0, 0), xrange(0, 3), intCompare, newInt, newInt, LeftJoin)
+  results, err := joinToStrings(s)
+  if len(results) != 0 {
+    t.Errorf("Expected no results, got %v", results)
+  }
+  verifyDone(t, s, new(Pair), err)
+  s = MergeJoin(xrange(0, 0), xrange(0, 2), intCompare, newInt, newInt, RightJoin)
+  results, err = joinToStrings(s)
+  if output := fmt.Sprintf("%v", results); output != "[-:0 -:1]" {
+    t.Errorf("Expected [-:0 -:1] got %v", output)
+  }
+  verifyDone(t, s, new(Pair), err)
+}
+
+func TestMergeJoinError(t *testing.T) {
+  s := MergeJoin(
+      NewStreamFromValues([]int{1, 1, 2}, nil),
+      Filter(
+          NewFilterer(func(ptr interface{}) error {
+            if *ptr.(*int) == 3 {
+              return scanError
+            }
+            return nil
+          }),
+          NewStreamFromValues([]int{1, 3, 2}, nil)),
+      intCompare,
+      newInt,
+      newInt,
+      InnerJoin)
+  var p Pair
+  expected := []error{scanError, nil, nil, nil, Done}
+  expectedValues := []string{"-:-", "1:1", "1:1", "2:2", "2:2"}
+  for i := range expected {
+    err := s.Next(&p)
+    if output := pairToString(&p); err != expected[i] || output != expectedValues[i] {
+      t.Errorf("Expected %v %v, got %v %v", expectedValues[i], expected[i], output, err)
+    }
+  }
+}
+
+func TestMergeJoinClose(t *testing.T) {
+  x := &streamCloseChecker{NilStream(), &simpleCloseChecker{}}
+  y := &streamCloseChecker{NilStream(), &simpleCloseChecker{closeError: closeError}}
+  s := MergeJoin(x, y, intCompare, newInt, newInt, FullJoin)
+  closeVerifyResult(t, s, closeError)
+  verifyCloseCalled(t, x, true)
+  verifyCloseCalled(t, y, true)
+}
+
+func intCompare(l, r interface{}) int {
+  return *l.(*int) - *r.(*int)
+}
+
+func pairToString(p *Pair) string {
+  l, r := "-", "-"
+  if p.Left != nil {
+    l = fmt.Sprintf("%d", *p.Left.(*int))
+  }
+  if p.Right != nil {
+    r = fmt.Sprintf("%d", *p.Right.(*int))
+  }
+  return l + ":" + r
+}
+
+func joinToStrings(s Stream) (result []string, err error) {
+  var p Pair
+  for err = s.Next(&p); err == nil; err = s.Next(&p) {
+    result = append(result, pairToString(&p))
+  }
+  return
+}
@@ -0,0 +1,39 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// MergeOptions controls MergeWithSource.
+type MergeOptions struct {
+  // If true, the merged Stream keeps reading a Stream after it reports an
+  // error. If false, the merged Stream reports the error once and then
+  // stops reading that Stream, like Merge does. A Stream that reports
+  // errors forever keeps the merged Stream from ever returning Done.
+  ContinueOnError bool
+  // If true, check that each Stream emits values in order, as in
+  // MergeChecked. Out of order values are reported as *ErrOutOfOrder
+  // errors instead of being emitted.
+  CheckOrder bool
+}
+
+// MergeWithSource works like Merge, but the returned Stream emits
+// a Tuple of (int, T) where the int is the index of the Stream in streams
+// that the T value came from. When values from different Streams are
+// equal, the one from the Stream with the lower index comes first, so the
+// merge is stable. options may be nil for the defaults.
+func MergeWithSource(
+    creater Creater,
+    copier Copier,
+    before func(lhs, rhs interface{}) bool,
+    options *MergeOptions,
+    streams ...Stream) Stream {
+  if len(streams) == 0 {
+    return nilS
+  }
+  if options == nil {
+    options = &MergeOptions{}
+  }
+  return merge(creater, copier, before, options, true, streams)
+}
@@ -0,0 +1,122 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestMergeWithSource(t *testing.T) {
+  s := MergeWithSource(
+      newInt,
+      nil,
+      intBefore,
+      nil,
+      NewStreamFromValues([]int{3, 5, 5}, nil),
+      NewStreamFromValues([]int{1, 3, 5}, nil),
+      NewStreamFromValues([]int{3, 4}, nil))
+  results, err := sourcesToStrings(s)
+  expected := "[1:1 0:3 1:3 2:3 2:4 0:5 0:5 1:5]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v got %v", expected, output)
+  }
+  verifyDone(t, s, &intAndInt{}, err)
+}
+
+func TestMergeWithSourceError(t *testing.T) {
+  newStreams := func() []Stream {
+    return []Stream{
+        NewStreamFromValues([]int{2, 4}, nil),
+        Filter(
+            NewFilterer(func(ptr interface{}) error {
+              if *ptr.(*int) == 3 {
+                return scanError
+              }
+              return nil
+            }),
+            NewStreamFromValues([]int{1, 3, 5}, nil))}
+  }
+  s := MergeWithSource(newInt, nil, intBefore, nil, newStreams()...)
+  results, err := sourcesToStrings(s)
+  if output := fmt.Sprintf("%v %v", results, err); output != "[1:1] error scanning." {
+    t.Errorf("Expected [1:1] error scanning. got %v", output)
+  }
+  results, err = sourcesToStrings(s)
+  if output := fmt.Sprintf("%v", results); output != "[0:2 0:4]" {
+    t.Errorf("Expected [0:2 0:4] got %v", output)
+  }
+  verifyDone(t, s, &intAndInt{}, err)
+
+  s = MergeWithSource(
+      newInt,
+      nil,
+      intBefore,
+      &MergeOptions{ContinueOnError: true},
+      newStreams()...)
+  sourcesToStrings(s)
+  results, err = sourcesToStrings(s)
+  if output := fmt.Sprintf("%v", results); output != "[0:2 0:4 1:5]" {
+    t.Errorf("Expected [0:2 0:4 1:5] got %v", output)
+  }
+  verifyDone(t, s, &intAndInt{}, err)
+}
+
+func TestMergeWithSourceCheckOrder(t *testing.T) {
+  s := MergeWithSource(
+      newInt,
+      nil,
+      intBefore,
+      &MergeOptions{ContinueOnError: true, CheckOrder: true},
+      NewStreamFromValues([]int{2, 6}, nil),
+      NewStreamFromValues([]int{1, 4, 3, 5}, nil))
+  results, err := sourcesToStrings(s)
+  if output := fmt.Sprintf("%v %v", results, err); output != "[1:1 0:2 1:4] functional: Input 1 out of order at position 2." {
+    t.Errorf("Expected out of order error got %v", output)
+  }
+  results, err = sourcesToStrings(s)
+  if output := fmt.Sprintf("%v", results); output != "[1:5 0:6]" {
+    t.Errorf("Expected [1:5 0:6] got %v", output)
+  }
+  verifyDone(t, s, &intAndInt{}, err)
+
+  s = MergeWithSource(
+      newInt,
+      nil,
+      intBefore,
+      &MergeOptions{ContinueOnError: true, CheckOrder: true},
+      NewStreamFromValues([]int{2, 6}, nil),
+      // Reports an error without touching the value passed to Next.
+      FromSeq2(func(yield func(int, error) bool) {
+        if !yield(1, nil) || !yield(5, nil) || !yield(0, scanError) {
+          return
+        }
+        yield(3, nil)
+      }))
+  results, err = sourcesToStrings(s)
+  if output := fmt.Sprintf("%v %v", results, err); output != "[1:1 0:2 1:5] error scanning." {
+    t.Errorf("Expected [1:1 0:2 1:5] error scanning. got %v", output)
+  }
+  // 3 must be checked against 5, the last value before the error.
+  results, err = sourcesToStrings(s)
+  if output := fmt.Sprintf("%v %v", results, err); output != "[] functional: Input 1 out of order at position 2." {
+    t.Errorf("Expected out of order error got %v", output)
+  }
+  results, err = sourcesToStrings(s)
+  if output := fmt.Sprintf("%v", results); output != "[0:6]" {
+    t.Errorf("Expected [0:6] got %v", output)
+  }
+  verifyDone(t, s, &intAndInt{}, err)
+  closeVerifyResult(t, s, nil)
+}
+
+func sourcesToStrings(s Stream) (result []string, err error) {
+  var x intAndInt
+  for err = s.Next(&x); err == nil; err = s.Next(&x) {
+    result = append(result, fmt.Sprintf("%d:%d", x.x, x.y))
+  }
+  return
+}
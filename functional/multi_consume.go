@@ -0,0 +1,446 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "errors"
+  "sync"
+  "sync/atomic"
+  "time"
+)
+
+var (
+  // ErrConsumerDropped indicates that MultiConsumeWithOptions stopped
+  // sending values to a consumer because it fell too far behind.
+  ErrConsumerDropped = errors.New("functional: Consumer dropped for falling behind.")
+  // ErrCanceled indicates that MultiConsumeWithOptions stopped sending
+  // values to a consumer because another consumer failed.
+  ErrCanceled = errors.New("functional: Consumer canceled.")
+)
+
+// dropSlowGrace is how long a consumer with a full queue may hold up
+// another consumer before it is dropped. A consumer that keeps up can
+// still have a full queue for a moment if its goroutine hasn't run yet.
+var dropSlowGrace = 10 * time.Millisecond
+
+// MultiConsumeOptions controls how MultiConsumeWithOptions sends values to
+// its consumers.
+type MultiConsumeOptions struct {
+  // QueueDepth is the maximum number of values that can be waiting for
+  // each consumer. Values less than 1 mean 1.
+  QueueDepth int
+  // If DropSlowConsumers is true, a consumer whose queue is full is dropped
+  // if it holds up another consumer that is waiting for values and its
+  // queue is still full after a short grace period.
+  // Next on the Stream of a dropped consumer returns ErrConsumerDropped once
+  // and then Done.
+  DropSlowConsumers bool
+  // If CopyOnEnqueue is true, each consumer gets its own copy of each value
+  // at the time the value is queued. Otherwise consumers share one copy of
+  // each value and copy it out when they call Next, so copier must be safe
+  // to call from multiple goroutines at once with the same source.
+  CopyOnEnqueue bool
+  // If CancelOnError is true, the remaining consumers are canceled as soon
+  // as the Consume method of any consumer returns an error. Next on the
+  // Stream of a canceled consumer returns ErrCanceled once and then Done.
+  CancelOnError bool
+}
+
+// MultiConsumeWithOptions works like MultiConsume except that each consumer
+// gets a queue of values so that a slow consumer does not slow down the
+// others until its queue fills up. creater is a Creater of T used to
+// allocate storage for queued values. copier is a Copier of T; nil means
+// simple assignment. options controls the size of the queues and what happens
+// when one fills up; nil means the zero MultiConsumeOptions.
+// MultiConsumeWithOptions returns all the errors from the individual Consume
+// methods in the order of the consumers. The error for a dropped consumer is
+// ErrConsumerDropped unless its Consume method reports a different error.
+// Likewise, the error for a consumer that saw ErrCanceled is ErrCanceled
+// unless its Consume method reports a different error.
+func MultiConsumeWithOptions(
+    s Stream,
+    creater Creater,
+    copier Copier,
+    options *MultiConsumeOptions,
+    consumers ...Consumer) (errors []error) {
+  consumerLen := len(consumers)
+  if consumerLen == 0 {
+    return
+  }
+  if options == nil {
+    options = &MultiConsumeOptions{}
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  depth := options.QueueDepth
+  if depth < 1 {
+    depth = 1
+  }
+  p := &mcProducer{
+      creater: creater,
+      copier: copier,
+      dropSlow: options.DropSlowConsumers,
+      copyOnEnqueue: options.CopyOnEnqueue,
+      free: make(chan *mcSlot, consumerLen * (depth + 1) + 1),
+      hungry: make(chan struct{}, 1),
+      streams: make([]*mcStream, consumerLen)}
+  var cancelOnce sync.Once
+  var cancel chan struct{}
+  if options.CancelOnError {
+    cancel = make(chan struct{})
+  }
+  errors = make([]error, consumerLen)
+  var wg sync.WaitGroup
+  for i := range p.streams {
+    p.streams[i] = &mcStream{
+        queue: make(chan *mcSlot, depth),
+        quit: make(chan struct{}),
+        hungry: p.hungry,
+        cancel: cancel,
+        copier: copier}
+    wg.Add(1)
+    go func(s *mcStream, c Consumer, e *error) {
+      defer wg.Done()
+      *e = c.Consume(s)
+      close(s.quit)
+      if *e != nil && cancel != nil {
+        cancelOnce.Do(func() { close(cancel) })
+      }
+      // Release whatever is still queued until the producer stops sending.
+      for slot := range s.queue {
+        slot.release()
+      }
+    }(p.streams[i], consumers[i], &errors[i])
+  }
+  p.run(s)
+  wg.Wait()
+  for i := range p.streams {
+    if errors[i] == nil {
+      if p.streams[i].isDropped() {
+        errors[i] = ErrConsumerDropped
+      } else {
+        errors[i] = p.streams[i].reported
+      }
+    }
+  }
+  return
+}
+
+// CompositeConsumerWithOptions works like CompositeConsumer except that it
+// sends values to consumers with MultiConsumeWithOptions.
+// creater, copier, and options are as in MultiConsumeWithOptions.
+// The returned Consumer always reports failures with a ConsumerErrors value,
+// even when consumers has just one Consumer.
+func CompositeConsumerWithOptions(
+    creater Creater,
+    copier Copier,
+    options *MultiConsumeOptions,
+    consumers ...Consumer) Consumer {
+  if len(consumers) == 0 {
+    return nilConsumer{}
+  }
+  return ConsumerFunc(func(s Stream) error {
+    return newConsumerErrors(
+        MultiConsumeWithOptions(s, creater, copier, options, consumers...))
+  })
+}
+
+// mcSlot holds one value or error on its way to the consumers.
+type mcSlot struct {
+  value interface{}
+  err error
+  // refs is the number of queues this slot is in or is being copied from.
+  refs int32
+  free chan *mcSlot
+}
+
+func (s *mcSlot) release() {
+  if atomic.AddInt32(&s.refs, -1) == 0 {
+    s.free <- s
+  }
+}
+
+// mcStream is the Stream that MultiConsumeWithOptions passes to a consumer.
+type mcStream struct {
+  queue chan *mcSlot
+  // quit is closed when the consumer's Consume method returns.
+  quit chan struct{}
+  // hungry is shared by all the consumers' Streams. Next signals it when
+  // the queue is empty.
+  hungry chan struct{}
+  // cancel is shared by all the consumers' Streams. It is closed when the
+  // consumers are canceled; it is nil if they can't be.
+  cancel chan struct{}
+  copier Copier
+  // queueClosed is true once the producer has closed queue. Only the
+  // producer goroutine uses it.
+  queueClosed bool
+  // exhausted is true if the producer closed queue because there were no
+  // more values. It is set before queue is closed.
+  exhausted bool
+  dropped int32
+  // reported is the error Next returned instead of Done, if any.
+  reported error
+}
+
+func (s *mcStream) Next(ptr interface{}) error {
+  if ptr == nil {
+    panic("Got nil pointer in Next.")
+  }
+  if s.reported != nil {
+    return Done
+  }
+  if !s.isDropped() && !s.isCanceled() {
+    slot, closed := s.receive()
+    if slot != nil {
+      err := slot.err
+      if err == nil {
+        s.copier(slot.value, ptr)
+      }
+      slot.release()
+      return err
+    }
+    if closed && s.exhausted {
+      return Done
+    }
+  }
+  // Any slots left in the queue get released after Consume returns.
+  if s.isDropped() {
+    s.reported = ErrConsumerDropped
+    return s.reported
+  }
+  if s.isCanceled() {
+    s.reported = ErrCanceled
+    return s.reported
+  }
+  return Done
+}
+
+// receive returns the next slot in queue. If there is none, receive returns
+// nil and whether queue is closed.
+func (s *mcStream) receive() (slot *mcSlot, closed bool) {
+  var ok bool
+  select {
+  case slot, ok = <-s.queue:
+    return slot, !ok
+  default:
+  }
+  select {
+  case s.hungry <- struct{}{}:
+  default:
+  }
+  select {
+  case slot, ok = <-s.queue:
+    return slot, !ok
+  case <-s.cancel:
+    return nil, false
+  }
+}
+
+func (s *mcStream) Close() error {
+  return nil
+}
+
+func (s *mcStream) isDropped() bool {
+  return atomic.LoadInt32(&s.dropped) != 0
+}
+
+func (s *mcStream) isCanceled() bool {
+  select {
+  case <-s.cancel:
+    return true
+  default:
+    return false
+  }
+}
+
+// isDone returns true if the producer should stop sending to this Stream.
+func (s *mcStream) isDone() bool {
+  if s.queueClosed {
+    return true
+  }
+  select {
+  case <-s.quit:
+    return true
+  case <-s.cancel:
+    return true
+  default:
+    return false
+  }
+}
+
+// closeQueue closes queue. exhausted is true if the consumer got all the
+// values.
+func (s *mcStream) closeQueue(exhausted bool) {
+  if !s.queueClosed {
+    s.queueClosed = true
+    s.exhausted = exhausted
+    close(s.queue)
+  }
+}
+
+// mcProducer reads values from the source Stream and queues them for the
+// consumers.
+type mcProducer struct {
+  creater Creater
+  copier Copier
+  dropSlow bool
+  copyOnEnqueue bool
+  free chan *mcSlot
+  hungry chan struct{}
+  streams []*mcStream
+}
+
+func (p *mcProducer) run(s Stream) {
+  exhausted := false
+  defer func() {
+    for i := range p.streams {
+      p.streams[i].closeQueue(exhausted)
+    }
+  }()
+  var ptr interface{}
+  if p.copyOnEnqueue {
+    ptr = p.creater()
+  }
+  for p.anyAccepting() {
+    if p.copyOnEnqueue {
+      err := s.Next(ptr)
+      if err == Done {
+        exhausted = true
+        return
+      }
+      for _, stream := range p.streams {
+        if stream.isDone() {
+          continue
+        }
+        slot := p.newSlot(1)
+        slot.err = err
+        if err == nil {
+          p.copier(ptr, slot.value)
+        }
+        p.send(stream, slot)
+      }
+    } else {
+      slot := p.newSlot(1)
+      slot.err = s.Next(slot.value)
+      if slot.err == Done {
+        slot.release()
+        exhausted = true
+        return
+      }
+      for _, stream := range p.streams {
+        if !stream.isDone() {
+          atomic.AddInt32(&slot.refs, 1)
+          p.send(stream, slot)
+        }
+      }
+      // Give up the reference we took for ourselves.
+      slot.release()
+    }
+  }
+}
+
+// anyAccepting returns true if any consumer is still accepting values.
+func (p *mcProducer) anyAccepting() bool {
+  for i := range p.streams {
+    if !p.streams[i].isDone() {
+      return true
+    }
+  }
+  return false
+}
+
+// send sends slot to stream, dropping stream if it holds up another
+// consumer for longer than dropSlowGrace. The caller must already have
+// counted stream in the references of slot.
+func (p *mcProducer) send(stream *mcStream, slot *mcSlot) {
+  if !p.dropSlow {
+    select {
+    case stream.queue <- slot:
+    case <-stream.quit:
+      slot.release()
+    case <-stream.cancel:
+      slot.release()
+    }
+    return
+  }
+  for {
+    select {
+    case stream.queue <- slot:
+      return
+    default:
+    }
+    select {
+    case stream.queue <- slot:
+      return
+    case <-stream.quit:
+      slot.release()
+      return
+    case <-stream.cancel:
+      slot.release()
+      return
+    case <-p.hungry:
+      // select may pick this case even when queue has room. Since we are
+      // the only sender, sending can't block then.
+      if len(stream.queue) < cap(stream.queue) {
+        stream.queue <- slot
+        return
+      }
+      if !p.anyStarving(stream) {
+        continue
+      }
+      if !p.sendWithin(stream, slot, dropSlowGrace) {
+        atomic.StoreInt32(&stream.dropped, 1)
+        stream.closeQueue(false)
+        slot.release()
+      }
+      return
+    }
+  }
+}
+
+// sendWithin tries to send slot to stream for up to d. It returns true if
+// it sent slot or released it because stream is done.
+func (p *mcProducer) sendWithin(
+    stream *mcStream, slot *mcSlot, d time.Duration) bool {
+  timer := time.NewTimer(d)
+  defer timer.Stop()
+  select {
+  case stream.queue <- slot:
+  case <-stream.quit:
+    slot.release()
+  case <-stream.cancel:
+    slot.release()
+  case <-timer.C:
+    return false
+  }
+  return true
+}
+
+// anyStarving returns true if a consumer other than stream has an empty
+// queue.
+func (p *mcProducer) anyStarving(stream *mcStream) bool {
+  for _, other := range p.streams {
+    if other != stream && !other.isDone() && len(other.queue) == 0 {
+      return true
+    }
+  }
+  return false
+}
+
+// newSlot returns an unused slot with refs references.
+func (p *mcProducer) newSlot(refs int32) *mcSlot {
+  var slot *mcSlot
+  select {
+  case slot = <-p.free:
+  default:
+    slot = &mcSlot{value: p.creater(), free: p.free}
+  }
+  slot.err = nil
+  slot.refs = refs
+  return slot
+}
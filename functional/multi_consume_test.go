@@ -0,0 +1,283 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestMultiConsumeWithOptions(t *testing.T) {
+  for _, copyOnEnqueue := range []bool{false, true} {
+    ec := &intConsumer{}
+    oc := &intConsumer{}
+    errors := MultiConsumeWithOptions(
+        xrange(0, 10),
+        newInt,
+        nil,
+        &MultiConsumeOptions{QueueDepth: 3, CopyOnEnqueue: copyOnEnqueue},
+        FilterConsumer(ec, evenFilterer),
+        FilterConsumer(oc, oddFilterer))
+    if len(errors) != 2 || errors[0] != nil || errors[1] != nil {
+      t.Errorf("Expected no errors, got %v", errors)
+    }
+    if output := fmt.Sprintf("%v", ec.results); output != "[0 2 4 6 8]" {
+      t.Errorf("Expected [0 2 4 6 8] got %v", output)
+    }
+    if output := fmt.Sprintf("%v", oc.results); output != "[1 3 5 7 9]" {
+      t.Errorf("Expected [1 3 5 7 9] got %v", output)
+    }
+  }
+}
+
+func TestMultiConsumeWithOptionsCopier(t *testing.T) {
+  for _, copyOnEnqueue := range []bool{false, true} {
+    c1 := &intConsumer{}
+    c2 := &intConsumer{}
+    MultiConsumeWithOptions(
+        xrange(1, 4),
+        newInt,
+        squareIntCopier,
+        &MultiConsumeOptions{CopyOnEnqueue: copyOnEnqueue},
+        c1,
+        c2)
+    // With CopyOnEnqueue each value gets copied twice.
+    expected := "[1 4 9]"
+    if copyOnEnqueue {
+      expected = "[1 16 81]"
+    }
+    for _, c := range []*intConsumer{c1, c2} {
+      if output := fmt.Sprintf("%v", c.results); output != expected {
+        t.Errorf("Expected %v got %v", expected, output)
+      }
+    }
+  }
+}
+
+func TestMultiConsumeWithOptionsErrors(t *testing.T) {
+  s := Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if *ptr.(*int) == 1 {
+          return scanError
+        }
+        return nil
+      }),
+      xrange(0, 3))
+  var errs1, errs2 []error
+  consumer := func(errs *[]error) Consumer {
+    return ConsumerFunc(func(s Stream) error {
+      var x int
+      err := s.Next(&x)
+      for ; err != Done; err = s.Next(&x) {
+        *errs = append(*errs, err)
+      }
+      return nil
+    })
+  }
+  MultiConsumeWithOptions(
+      s, newInt, nil, nil, consumer(&errs1), consumer(&errs2))
+  expected := fmt.Sprintf("%v", []error{nil, scanError, nil})
+  if output := fmt.Sprintf("%v", errs1); output != expected {
+    t.Errorf("Expected %v got %v", expected, output)
+  }
+  if output := fmt.Sprintf("%v", errs2); output != expected {
+    t.Errorf("Expected %v got %v", expected, output)
+  }
+}
+
+func TestMultiConsumeWithOptionsStopsEarly(t *testing.T) {
+  c1 := &intConsumer{}
+  c2 := &intConsumer{}
+  errors := MultiConsumeWithOptions(
+      Count(),
+      newInt,
+      nil,
+      &MultiConsumeOptions{QueueDepth: 5},
+      ModifyConsumer(c1, func(s Stream) Stream { return Slice(s, 0, 2) }),
+      ModifyConsumer(c2, func(s Stream) Stream { return Slice(s, 0, 4) }))
+  if len(errors) != 2 || errors[0] != nil || errors[1] != nil {
+    t.Errorf("Expected no errors, got %v", errors)
+  }
+  if output := fmt.Sprintf("%v %v", c1.results, c2.results); output != "[0 1] [0 1 2 3]" {
+    t.Errorf("Expected [0 1] [0 1 2 3] got %v", output)
+  }
+}
+
+func TestMultiConsumeWithOptionsDropSlowConsumers(t *testing.T) {
+  for _, copyOnEnqueue := range []bool{false, true} {
+    release := make(chan struct{})
+    slow := &intConsumer{}
+    var first int
+    slowConsumer := ConsumerFunc(func(s Stream) error {
+      s.Next(&first)
+      // Fall behind until the fast consumer is done.
+      <-release
+      return slow.Consume(s)
+    })
+    fast := &intConsumer{}
+    fastConsumer := ConsumerFunc(func(s Stream) error {
+      defer close(release)
+      return fast.Consume(s)
+    })
+    errors := MultiConsumeWithOptions(
+        xrange(0, 10),
+        newInt,
+        nil,
+        &MultiConsumeOptions{
+            QueueDepth: 2,
+            DropSlowConsumers: true,
+            CopyOnEnqueue: copyOnEnqueue},
+        slowConsumer,
+        fastConsumer)
+    if len(errors) != 2 || errors[0] != ErrConsumerDropped || errors[1] != nil {
+      t.Errorf("Expected [ErrConsumerDropped <nil>], got %v", errors)
+    }
+    if first != 0 || len(slow.results) != 0 {
+      t.Errorf("Expected 0 [], got %v %v", first, slow.results)
+    }
+    if output := fmt.Sprintf("%v", fast.results); output != "[0 1 2 3 4 5 6 7 8 9]" {
+      t.Errorf("Expected [0 1 2 3 4 5 6 7 8 9] got %v", output)
+    }
+  }
+}
+
+func TestMultiConsumeWithOptionsDropSlowConsumersKeepingUp(t *testing.T) {
+  for _, copyOnEnqueue := range []bool{false, true} {
+    consumers := []*intConsumer{{}, {}, {}}
+    errors := MultiConsumeWithOptions(
+        xrange(0, 1000),
+        newInt,
+        nil,
+        &MultiConsumeOptions{
+            QueueDepth: 4,
+            DropSlowConsumers: true,
+            CopyOnEnqueue: copyOnEnqueue},
+        consumers[0],
+        consumers[1],
+        consumers[2])
+    for i := range errors {
+      if errors[i] != nil {
+        t.Errorf("Expected no error for consumer %d, got %v", i, errors[i])
+      }
+      if output := len(consumers[i].results); output != 1000 {
+        t.Errorf("Expected 1000 values for consumer %d, got %v", i, output)
+      }
+    }
+  }
+}
+
+func TestMultiConsumeWithOptionsDroppedStreamReturnsDone(t *testing.T) {
+  release := make(chan struct{})
+  var errs []error
+  slowConsumer := ConsumerFunc(func(s Stream) error {
+    <-release
+    var x int
+    for err := s.Next(&x); err != Done; err = s.Next(&x) {
+      errs = append(errs, err)
+    }
+    return nil
+  })
+  fastConsumer := ConsumerFunc(func(s Stream) error {
+    defer close(release)
+    _, err := toIntArray(s)
+    if err == Done {
+      err = nil
+    }
+    return err
+  })
+  errors := MultiConsumeWithOptions(
+      xrange(0, 5),
+      newInt,
+      nil,
+      &MultiConsumeOptions{DropSlowConsumers: true},
+      slowConsumer,
+      fastConsumer)
+  if output := fmt.Sprintf("%v", errs); output != fmt.Sprintf("%v", []error{ErrConsumerDropped}) {
+    t.Errorf("Expected only ErrConsumerDropped, got %v", output)
+  }
+  if errors[0] != ErrConsumerDropped {
+    t.Errorf("Expected ErrConsumerDropped, got %v", errors[0])
+  }
+}
+
+func TestCompositeConsumerWithOptions(t *testing.T) {
+  ec := &intConsumer{}
+  oc := ConsumerFunc(func(s Stream) error { return consumerError })
+  consumer := CompositeConsumerWithOptions(
+      newInt,
+      nil,
+      &MultiConsumeOptions{QueueDepth: 2},
+      ec,
+      oc)
+  err := consumer.Consume(Slice(Count(), 0, 5))
+  if errs, ok := err.(ConsumerErrors); !ok || len(errs) != 1 || errs[0].Index != 1 || errs[0].Err != consumerError {
+    t.Errorf("Expected consumerError from consumer 1, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", ec.results); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] got %v", output)
+  }
+  if CompositeConsumerWithOptions(newInt, nil, nil) != NilConsumer() {
+    t.Error("Expected composing zero consumers to be the Nil consumer.")
+  }
+}
+
+func TestMultiConsumeWithOptionsCancelOnError(t *testing.T) {
+  var errs []error
+  canceled := ConsumerFunc(func(s Stream) error {
+    var x int
+    for err := s.Next(&x); err != Done; err = s.Next(&x) {
+      if err != nil {
+        errs = append(errs, err)
+      }
+    }
+    return nil
+  })
+  failing := ConsumerFunc(func(s Stream) error {
+    var x int
+    s.Next(&x)
+    return consumerError
+  })
+  errors := MultiConsumeWithOptions(
+      Count(),
+      newInt,
+      nil,
+      &MultiConsumeOptions{CancelOnError: true},
+      canceled,
+      failing)
+  if len(errors) != 2 || errors[0] != ErrCanceled || errors[1] != consumerError {
+    t.Errorf("Expected [ErrCanceled consumerError], got %v", errors)
+  }
+  if output := fmt.Sprintf("%v", errs); output != fmt.Sprintf("%v", []error{ErrCanceled}) {
+    t.Errorf("Expected only ErrCanceled, got %v", output)
+  }
+}
+
+func TestMultiConsumeWithOptionsCancelAfterDone(t *testing.T) {
+  c := &intConsumer{}
+  finished := make(chan struct{})
+  succeeding := ConsumerFunc(func(s Stream) error {
+    defer close(finished)
+    return c.Consume(s)
+  })
+  failing := ConsumerFunc(func(s Stream) error {
+    toIntArray(s)
+    <-finished
+    return consumerError
+  })
+  errors := MultiConsumeWithOptions(
+      xrange(0, 3),
+      newInt,
+      nil,
+      &MultiConsumeOptions{CancelOnError: true, QueueDepth: 5},
+      succeeding,
+      failing)
+  if len(errors) != 2 || errors[0] != nil || errors[1] != consumerError {
+    t.Errorf("Expected [<nil> consumerError], got %v", errors)
+  }
+  if output := fmt.Sprintf("%v", c.results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+}
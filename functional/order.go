@@ -0,0 +1,89 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+)
+
+// ErrOutOfOrder indicates that a Stream emitted a value that comes before
+// the value it emitted just prior.
+type ErrOutOfOrder struct {
+  // Input is the index of the out of order Stream among the merged
+  // Streams. Always 0 for CheckSorted.
+  Input int
+  // Position is the 0-based position of the out of order value within
+  // its Stream.
+  Position int64
+}
+
+func (e *ErrOutOfOrder) Error() string {
+  return fmt.Sprintf(
+      "functional: Input %d out of order at position %d.",
+      e.Input, e.Position)
+}
+
+// MergeChecked works like Merge, but it checks that each Stream in streams
+// emits its values in order. When a Stream emits a value that comes before
+// its previous value, the returned Stream reports an *ErrOutOfOrder and,
+// as with any other error in Merge, stops reading that Stream.
+func MergeChecked(
+    creater Creater,
+    copier Copier,
+    before func(lhs, rhs interface{}) bool,
+    streams ...Stream) Stream {
+  if len(streams) == 0 {
+    return nilS
+  }
+  return merge(
+      creater, copier, before, &MergeOptions{CheckOrder: true}, false, streams)
+}
+
+// CheckSorted returns a Stream that emits the values of s, a Stream of T,
+// while checking that they are in order according to before. When s emits
+// a value that comes before its previous value, the returned Stream reports
+// an *ErrOutOfOrder instead of emitting it. Later values are checked
+// against the out of order value. creater is a Creater of T; copier is a
+// Copier of T where nil means regular assignment. Errors from s pass
+// through. Calling Close on returned Stream closes s.
+func CheckSorted(
+    s Stream,
+    before func(lhs, rhs interface{}) bool,
+    creater Creater,
+    copier Copier) Stream {
+  if copier == nil {
+    copier = assignCopier
+  }
+  return &checkSortedStream{
+      Stream: s,
+      before: before,
+      copier: copier,
+      current: creater(),
+      previous: creater()}
+}
+
+type checkSortedStream struct {
+  Stream
+  before func(lhs, rhs interface{}) bool
+  copier Copier
+  current interface{}
+  previous interface{}
+  position int64
+}
+
+func (s *checkSortedStream) Next(ptr interface{}) error {
+  if err := s.Stream.Next(s.current); err != nil {
+    return err
+  }
+  outOfOrder := s.position > 0 && s.before(s.current, s.previous)
+  s.current, s.previous = s.previous, s.current
+  s.position++
+  if outOfOrder {
+    return &ErrOutOfOrder{Position: s.position - 1}
+  }
+  s.copier(s.previous, ptr)
+  return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+)
+
+func TestMergeChecked(t *testing.T) {
+  s := MergeChecked(
+      newInt,
+      nil,
+      intBefore,
+      NewStreamFromValues([]int{1, 3, 3, 5}, nil),
+      NewStreamFromValues([]int{2, 3, 6}, nil))
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3 3 3 5 6]" {
+    t.Errorf("Expected [1 2 3 3 3 5 6] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestMergeCheckedOutOfOrder(t *testing.T) {
+  s := MergeChecked(
+      newInt,
+      nil,
+      intBefore,
+      NewStreamFromValues([]int{3, 6}, nil),
+      NewStreamFromValues([]int{1, 4, 2, 5}, nil))
+  var x int
+  expectedValues := []int{1, 3, 4, 4, 6, 6}
+  for i := range expectedValues {
+    err := s.Next(&x)
+    if i == 3 {
+      var ooo *ErrOutOfOrder
+      if !errors.As(err, &ooo) || ooo.Input != 1 || ooo.Position != 2 {
+        t.Errorf("Expected input 1 out of order at 2, got %v", err)
+      }
+    } else if i == 5 {
+      if err != Done {
+        t.Errorf("Expected Done, got %v", err)
+      }
+    } else if err != nil {
+      t.Errorf("Expected nil, got %v", err)
+    }
+    if x != expectedValues[i] {
+      t.Errorf("Expected %v, got %v", expectedValues[i], x)
+    }
+  }
+}
+
+func TestMergeCheckedSingle(t *testing.T) {
+  s := MergeChecked(newInt, nil, intBefore, NewStreamFromValues([]int{2, 1}, nil))
+  var x int
+  if err := s.Next(&x); err != nil || x != 2 {
+    t.Errorf("Expected 2 nil, got %v %v", x, err)
+  }
+  if err := s.Next(&x); err.Error() != "functional: Input 0 out of order at position 1." {
+    t.Errorf("Expected out of order error, got %v", err)
+  }
+  verifyDone(t, s, new(int), s.Next(&x))
+}
+
+func TestCheckSorted(t *testing.T) {
+  s := CheckSorted(
+      NewStreamFromValues([]int{1, 3, 2, 4, 0}, nil), intBefore, newInt, nil)
+  var x int
+  expectedPositions := []int64{-1, -1, 2, -1, 4}
+  expectedValues := []int{1, 3, 3, 4, 4}
+  for i := range expectedValues {
+    err := s.Next(&x)
+    if expectedPositions[i] == -1 {
+      if err != nil {
+        t.Errorf("Expected nil, got %v", err)
+      }
+    } else {
+      ooo, ok := err.(*ErrOutOfOrder)
+      if !ok || ooo.Input != 0 || ooo.Position != expectedPositions[i] {
+        t.Errorf("Expected out of order at %d, got %v", expectedPositions[i], err)
+      }
+    }
+    if x != expectedValues[i] {
+      t.Errorf("Expected %v, got %v", expectedValues[i], x)
+    }
+  }
+  verifyDone(t, s, new(int), s.Next(&x))
+}
+
+func TestCheckSortedClose(t *testing.T) {
+  x := &streamCloseChecker{NilStream(), &simpleCloseChecker{}}
+  s := CheckSorted(x, intBefore, newInt, nil)
+  closeVerifyResult(t, s, nil)
+  verifyCloseCalled(t, x, true)
+}
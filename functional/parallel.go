@@ -0,0 +1,225 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "sync"
+)
+
+// ParallelMap works like Map except that it calls the Map method of m on
+// workers goroutines at once. The returned Stream still emits values in the
+// same order as s, leaves out values for which m returns Skipped, and reports
+// errors from s and m in the order of the values that caused them.
+// m must be safe to use from multiple goroutines simultaneously. If m is
+// a CompositeMapper, each goroutine uses its own Fast() version of it.
+// s is read from a single background goroutine, never concurrently.
+// window is the maximum number of values of s that can be read ahead of the
+// caller; it should be at least workers. srcCreater is a Creater of T and
+// destCreater is a Creater of U; each is called window times to allocate
+// storage. copier is a Copier of U that copies mapped values to the caller;
+// nil means regular assignment. ParallelMap panics if workers or window
+// is less than 1.
+// The goroutines start the first time caller calls Next. Caller must call
+// Close on returned Stream or else these goroutines may never exit.
+// Calling Close on returned Stream stops the goroutines, waiting for any
+// call in progress to Next on s or to Map on m to finish, and then closes s.
+func ParallelMap(
+    m Mapper,
+    s Stream,
+    srcCreater Creater,
+    destCreater Creater,
+    copier Copier,
+    workers int,
+    window int) Stream {
+  return newParallelMapStream(
+      m, s, srcCreater, destCreater, copier, workers, window, true)
+}
+
+// ParallelMapUnordered works like ParallelMap except that the returned Stream
+// emits each mapped value as soon as any goroutine finishes mapping it, so
+// values and errors may come out in a different order than in s. Use it
+// when the order of the output does not matter.
+// Caller must call Close on returned Stream or else the goroutines it starts
+// may never exit. Calling Close on returned Stream stops the goroutines,
+// waiting for any call in progress to Next on s or to Map on m to finish,
+// and then closes s.
+func ParallelMapUnordered(
+    m Mapper,
+    s Stream,
+    srcCreater Creater,
+    destCreater Creater,
+    copier Copier,
+    workers int,
+    window int) Stream {
+  return newParallelMapStream(
+      m, s, srcCreater, destCreater, copier, workers, window, false)
+}
+
+type parallelItem struct {
+  src interface{}
+  dest interface{}
+  err error
+  ready chan struct{}
+}
+
+type parallelMapStream struct {
+  ordered bool
+  mapper Mapper
+  stream Stream
+  copier Copier
+  workers int
+  // free holds the items not in use
+  free chan *parallelItem
+  // work sends items to the mapping goroutines
+  work chan *parallelItem
+  // results holds the items read from stream in input order if ordered
+  // is true or in the order they finished otherwise.
+  results chan *parallelItem
+  quit chan struct{}
+  wg sync.WaitGroup
+  started bool
+  stopped bool
+  done bool
+}
+
+func (s *parallelMapStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  if !s.started {
+    s.start()
+  }
+  for {
+    item, ok := <-s.results
+    if !ok {
+      s.done = true
+      return Done
+    }
+    if s.ordered {
+      <-item.ready
+    }
+    err := item.err
+    if err == nil {
+      s.copier(item.dest, ptr)
+    }
+    s.free <- item
+    if err != Skipped {
+      return err
+    }
+  }
+}
+
+func (s *parallelMapStream) Close() error {
+  if s.started && !s.stopped {
+    s.stopped = true
+    close(s.quit)
+    s.wg.Wait()
+  }
+  return s.stream.Close()
+}
+
+func (s *parallelMapStream) start() {
+  s.started = true
+  s.wg.Add(s.workers + 1)
+  go s.readValues()
+  for i := 0; i < s.workers; i++ {
+    go s.mapValues(workerMapper(s.mapper))
+  }
+  go func() {
+    s.wg.Wait()
+    close(s.results)
+  }()
+}
+
+func (s *parallelMapStream) readValues() {
+  defer s.wg.Done()
+  defer close(s.work)
+  for {
+    var item *parallelItem
+    select {
+    case item = <-s.free:
+    case <-s.quit:
+      return
+    }
+    if item.err = s.stream.Next(item.src); item.err == Done {
+      return
+    }
+    if s.ordered {
+      s.results <- item
+    }
+    if item.err != nil {
+      s.finish(item)
+      continue
+    }
+    select {
+    case s.work <- item:
+    case <-s.quit:
+      return
+    }
+  }
+}
+
+func (s *parallelMapStream) mapValues(m Mapper) {
+  defer s.wg.Done()
+  for item := range s.work {
+    item.err = m.Map(item.src, item.dest)
+    s.finish(item)
+  }
+}
+
+// finish hands off an item that is ready for Next. In ordered mode, the
+// item is already in results.
+func (s *parallelMapStream) finish(item *parallelItem) {
+  if s.ordered {
+    item.ready <- struct{}{}
+  } else {
+    s.results <- item
+  }
+}
+
+func newParallelMapStream(
+    m Mapper,
+    s Stream,
+    srcCreater Creater,
+    destCreater Creater,
+    copier Copier,
+    workers int,
+    window int,
+    ordered bool) *parallelMapStream {
+  if workers < 1 {
+    panic("workers must be at least 1.")
+  }
+  if window < 1 {
+    panic("window must be at least 1.")
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  result := &parallelMapStream{
+      ordered: ordered,
+      mapper: m,
+      stream: s,
+      copier: copier,
+      workers: workers,
+      free: make(chan *parallelItem, window),
+      work: make(chan *parallelItem),
+      results: make(chan *parallelItem, window),
+      quit: make(chan struct{})}
+  for i := 0; i < window; i++ {
+    result.free <- &parallelItem{
+        src: srcCreater(),
+        dest: destCreater(),
+        ready: make(chan struct{}, 1)}
+  }
+  return result
+}
+
+func workerMapper(m Mapper) Mapper {
+  if cm, ok := m.(CompositeMapper); ok {
+    return cm.Fast()
+  }
+  return m
+}
@@ -0,0 +1,208 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "runtime"
+    "sort"
+    "testing"
+    "time"
+)
+
+var (
+  slowSquareMapper = NewMapper(func(srcPtr, destPtr interface{}) error {
+    p := srcPtr.(*int)
+    q := destPtr.(*int)
+    time.Sleep(time.Duration(*p % 4) * time.Millisecond)
+    *q = (*p) * (*p)
+    return nil
+  })
+)
+
+func TestParallelMap(t *testing.T) {
+  stream := ParallelMap(
+      slowSquareMapper, xrange(0, 10), newInt, newInt, nil, 3, 5)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 4 9 16 25 36 49 64 81]" {
+    t.Errorf("Expected [0 1 4 9 16 25 36 49 64 81] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestParallelMapSkipped(t *testing.T) {
+  m := Compose(
+      slowSquareMapper,
+      NewMapper(func(srcPtr, destPtr interface{}) error {
+        p := srcPtr.(*int)
+        if *p % 2 == 1 {
+          return Skipped
+        }
+        *destPtr.(*int) = *p
+        return nil
+      }),
+      newInt)
+  stream := ParallelMap(m, xrange(0, 10), newInt, newInt, nil, 4, 4)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 4 16 36 64]" {
+    t.Errorf("Expected [0 4 16 36 64] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestParallelMapErrorsInOrder(t *testing.T) {
+  m := NewMapper(func(srcPtr, destPtr interface{}) error {
+    p := srcPtr.(*int)
+    switch *p {
+    case 1:
+      time.Sleep(5 * time.Millisecond)
+      return mapError
+    case 2:
+      return filterError
+    }
+    *destPtr.(*int) = *p
+    return nil
+  })
+  source := Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if *ptr.(*int) == 4 {
+          return scanError
+        }
+        return nil
+      }),
+      xrange(0, 6))
+  stream := ParallelMap(m, source, newInt, newInt, nil, 3, 3)
+  var x int
+  expected := []error{nil, mapError, filterError, nil, scanError, nil, Done}
+  for i := range expected {
+    if err := stream.Next(&x); err != expected[i] {
+      t.Errorf("Expected %v, got %v", expected[i], err)
+    }
+  }
+  if x != 5 {
+    t.Errorf("Expected 5, got %v", x)
+  }
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestParallelMapWithCopier(t *testing.T) {
+  stream := ParallelMap(
+      addMapper(0), xrange(1, 4), newInt, newInt, squareIntCopier, 2, 2)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[1 4 9]" {
+    t.Errorf("Expected [1 4 9] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestParallelMapClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{closeError: closeError}}
+  stream := Slice(
+      ParallelMap(slowSquareMapper, s, newInt, newInt, nil, 3, 6), 0, 3)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 4]" {
+    t.Errorf("Expected [0 1 4] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  verifyCloseCalled(t, s, false)
+  closeVerifyResult(t, stream, closeError)
+  verifyCloseCalled(t, s, true)
+}
+
+func TestParallelMapCloseNotStarted(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  stream := ParallelMap(slowSquareMapper, s, newInt, newInt, nil, 3, 6)
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s, true)
+}
+
+func TestParallelMapUnordered(t *testing.T) {
+  stream := ParallelMapUnordered(
+      slowSquareMapper, xrange(0, 10), newInt, newInt, nil, 3, 5)
+  results, err := toIntArray(stream)
+  sort.Ints(results)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 4 9 16 25 36 49 64 81]" {
+    t.Errorf("Expected [0 1 4 9 16 25 36 49 64 81] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestParallelMapUnorderedSkippedAndErrors(t *testing.T) {
+  m := NewMapper(func(srcPtr, destPtr interface{}) error {
+    p := srcPtr.(*int)
+    switch *p % 3 {
+    case 1:
+      return Skipped
+    case 2:
+      return mapError
+    }
+    *destPtr.(*int) = *p
+    return nil
+  })
+  stream := ParallelMapUnordered(m, xrange(0, 9), newInt, newInt, nil, 2, 4)
+  var results []int
+  var errorCount int
+  var x int
+  err := stream.Next(&x)
+  for ; err != Done; err = stream.Next(&x) {
+    if err == mapError {
+      errorCount++
+    } else if err != nil {
+      t.Fatalf("Expected mapError, got %v", err)
+    } else {
+      results = append(results, x)
+    }
+  }
+  sort.Ints(results)
+  if output := fmt.Sprintf("%v", results); output != "[0 3 6]" {
+    t.Errorf("Expected [0 3 6] got %v", output)
+  }
+  if errorCount != 3 {
+    t.Errorf("Expected 3 errors, got %v", errorCount)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestParallelMapUnorderedClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  stream := Slice(
+      ParallelMapUnordered(slowSquareMapper, s, newInt, newInt, nil, 3, 6),
+      0,
+      3)
+  results, err := toIntArray(stream)
+  if len(results) != 3 {
+    t.Errorf("Expected 3 results, got %v", results)
+  }
+  verifyDone(t, stream, new(int), err)
+  verifyCloseCalled(t, s, false)
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s, true)
+}
+
+func TestParallelMapNoGoroutineLeak(t *testing.T) {
+  before := runtime.NumGoroutine()
+  for _, ordered := range []bool{true, false} {
+    stream := newParallelMapStream(
+        slowSquareMapper, Count(), newInt, newInt, nil, 4, 8, ordered)
+    var x int
+    for i := 0; i < 5; i++ {
+      stream.Next(&x)
+    }
+    stream.Close()
+  }
+  // The goroutine that closes results may need a moment to exit.
+  for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+    time.Sleep(time.Millisecond)
+  }
+  if after := runtime.NumGoroutine(); after > before {
+    t.Errorf("Expected %v goroutines, got %v", before, after)
+  }
+}
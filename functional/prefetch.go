@@ -0,0 +1,105 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "sync"
+)
+
+// Prefetch returns a Stream that emits the same values and errors as s in
+// the same order but calls Next on s from a background goroutine which
+// reads up to n values ahead of the caller. This lets a slow source and a
+// slow consumer of its values run at the same time.
+// creater is a Creater of T which Prefetch calls n times to allocate storage.
+// copier is a Copier of T; nil means regular assignment.
+// Prefetch panics if n is less than 1.
+// The goroutine starts the first time caller calls Next. Caller must call
+// Close on returned Stream or else the goroutine may never exit.
+// Calling Close on returned Stream stops the goroutine, waiting for any
+// call in progress to Next on s to finish, and then closes s.
+func Prefetch(s Stream, n int, creater Creater, copier Copier) Stream {
+  if n < 1 {
+    panic("n must be at least 1.")
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  result := &prefetchStream{
+      stream: s,
+      copier: copier,
+      free: make(chan *prefetchItem, n),
+      results: make(chan *prefetchItem, n),
+      quit: make(chan struct{})}
+  for i := 0; i < n; i++ {
+    result.free <- &prefetchItem{value: creater()}
+  }
+  return result
+}
+
+type prefetchItem struct {
+  value interface{}
+  err error
+}
+
+type prefetchStream struct {
+  stream Stream
+  copier Copier
+  free chan *prefetchItem
+  results chan *prefetchItem
+  quit chan struct{}
+  wg sync.WaitGroup
+  started bool
+  stopped bool
+  done bool
+}
+
+func (s *prefetchStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  if !s.started {
+    s.started = true
+    s.wg.Add(1)
+    go s.readValues()
+  }
+  item, ok := <-s.results
+  if !ok {
+    s.done = true
+    return Done
+  }
+  err := item.err
+  if err == nil {
+    s.copier(item.value, ptr)
+  }
+  s.free <- item
+  return err
+}
+
+func (s *prefetchStream) Close() error {
+  if s.started && !s.stopped {
+    s.stopped = true
+    close(s.quit)
+    s.wg.Wait()
+  }
+  return s.stream.Close()
+}
+
+func (s *prefetchStream) readValues() {
+  defer s.wg.Done()
+  defer close(s.results)
+  for {
+    var item *prefetchItem
+    select {
+    case item = <-s.free:
+    case <-s.quit:
+      return
+    }
+    if item.err = s.stream.Next(item.value); item.err == Done {
+      return
+    }
+    s.results <- item
+  }
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestPrefetch(t *testing.T) {
+  stream := Prefetch(xrange(0, 10), 3, newInt, nil)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3 4 5 6 7 8 9]" {
+    t.Errorf("Expected [0 1 2 3 4 5 6 7 8 9] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestPrefetchEmpty(t *testing.T) {
+  stream := Prefetch(NilStream(), 1, newInt, nil)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[]" {
+    t.Errorf("Expected [] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestPrefetchErrors(t *testing.T) {
+  source := Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if *ptr.(*int) % 2 == 1 {
+          return scanError
+        }
+        return nil
+      }),
+      xrange(0, 4))
+  stream := Prefetch(source, 2, newInt, squareIntCopier)
+  var x int
+  expected := []error{nil, scanError, nil, scanError, Done}
+  values := []int{0, 0, 4, 4, 4}
+  for i := range expected {
+    if err := stream.Next(&x); err != expected[i] {
+      t.Errorf("Expected %v, got %v", expected[i], err)
+    }
+    if x != values[i] {
+      t.Errorf("Expected %v, got %v", values[i], x)
+    }
+  }
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestPrefetchClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{closeError: closeError}}
+  stream := Slice(Prefetch(s, 4, newInt, nil), 0, 2)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1]" {
+    t.Errorf("Expected [0 1] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  verifyCloseCalled(t, s, false)
+  closeVerifyResult(t, stream, closeError)
+  verifyCloseCalled(t, s, true)
+}
+
+func TestPrefetchCloseNotStarted(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  stream := Prefetch(s, 4, newInt, nil)
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s, true)
+}
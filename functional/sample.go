@@ -0,0 +1,27 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "math/rand"
+)
+
+// Sample returns a Stream that emits each value of s with the given
+// probability independently of the other values. Errors from s pass
+// through. rng is the source of randomness; pass a rand.Rand with a fixed
+// seed for repeatable results. Since rand.Rand is not safe for concurrent
+// use, rng should not be shared with other goroutines.
+// Calling Close on returned Stream closes s.
+func Sample(s Stream, probability float64, rng *rand.Rand) Stream {
+  return Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if rng.Float64() < probability {
+          return nil
+        }
+        return Skipped
+      }),
+      s)
+}
@@ -0,0 +1,56 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "math/rand"
+    "testing"
+)
+
+func TestSample(t *testing.T) {
+  results, err := toIntArray(
+      Sample(xrange(0, 10000), 0.1, rand.New(rand.NewSource(1))))
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if len(results) < 900 || len(results) > 1100 {
+    t.Errorf("Expected about 1000 values, got %v", len(results))
+  }
+  for i := 1; i < len(results); i++ {
+    if results[i] <= results[i - 1] {
+      t.Fatalf("Expected values in order, got %v then %v", results[i - 1], results[i])
+    }
+  }
+}
+
+func TestSampleRepeatable(t *testing.T) {
+  first, _ := toIntArray(
+      Sample(xrange(0, 100), 0.5, rand.New(rand.NewSource(7))))
+  second, _ := toIntArray(
+      Sample(xrange(0, 100), 0.5, rand.New(rand.NewSource(7))))
+  if fmt.Sprintf("%v", first) != fmt.Sprintf("%v", second) {
+    t.Errorf("Expected same sample, got %v and %v", first, second)
+  }
+}
+
+func TestSampleAllOrNothing(t *testing.T) {
+  rng := rand.New(rand.NewSource(1))
+  results, _ := toIntArray(Sample(xrange(0, 5), 1.0, rng))
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] got %v", output)
+  }
+  results, _ = toIntArray(Sample(xrange(0, 5), 0.0, rng))
+  if len(results) != 0 {
+    t.Errorf("Expected no values, got %v", results)
+  }
+}
+
+func TestSampleClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{closeError: closeError}}
+  closeVerifyResult(t, Sample(s, 0.5, rand.New(rand.NewSource(1))), closeError)
+  verifyCloseCalled(t, s, true)
+}
@@ -0,0 +1,197 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// Union works like Merge except that the returned Stream emits each
+// distinct value just once. Two T values are the same if neither comes
+// before the other. Calling Close on returned Stream closes all underlying
+// streams. If caller passes a slice to Union, no copy is made of it.
+func Union(
+    creater Creater,
+    copier Copier,
+    before func(lhs, rhs interface{}) bool,
+    streams ...Stream) Stream {
+  return Dedupe(
+      Merge(creater, copier, before, streams...),
+      func(lhs, rhs interface{}) bool {
+        return !before(lhs, rhs) && !before(rhs, lhs)
+      },
+      creater,
+      copier)
+}
+
+// Intersect returns a Stream that emits each distinct value found in every
+// one of streams in order. Like Merge, each Stream in streams must emit
+// its values in order according to before, and two T values are the same if
+// neither comes before the other. The returned Stream reports errors from
+// streams as it finds them. creater is a Creater of T that Intersect calls
+// twice for each Stream; copier is a Copier of T where nil means regular
+// assignment. Calling Close on returned Stream closes all underlying streams.
+// If caller passes a slice to Intersect, no copy is made of it.
+func Intersect(
+    creater Creater,
+    copier Copier,
+    before func(lhs, rhs interface{}) bool,
+    streams ...Stream) Stream {
+  if len(streams) == 0 {
+    return nilS
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  return &intersectStream{
+      setInputs: newSetInputs(creater, before, streams),
+      copier: copier,
+      before: before}
+}
+
+// Difference returns a Stream that emits each distinct value of s that is
+// not in any of others in order. s and each Stream in others must emit
+// values in order according to before. creater, copier, and before are as
+// in Intersect. Calling Close on returned Stream closes s and all of others.
+func Difference(
+    creater Creater,
+    copier Copier,
+    before func(lhs, rhs interface{}) bool,
+    s Stream,
+    others ...Stream) Stream {
+  if copier == nil {
+    copier = assignCopier
+  }
+  streams := make([]Stream, len(others) + 1)
+  streams[0] = s
+  copy(streams[1:], others)
+  return &differenceStream{
+      setInputs: newSetInputs(creater, before, streams),
+      copier: copier,
+      before: before}
+}
+
+// setInput is one of the sorted Streams of a set operation.
+type setInput struct {
+  stream Stream
+  current interface{}
+  // previous holds the value before current.
+  previous interface{}
+  e error
+}
+
+func (s *setInput) advance() {
+  s.current, s.previous = s.previous, s.current
+  s.e = s.stream.Next(s.current)
+}
+
+// skipSame advances past current and any values equal to it.
+func (s *setInput) skipSame(before func(lhs, rhs interface{}) bool) {
+  s.advance()
+  for s.e == nil && !before(s.previous, s.current) {
+    s.advance()
+  }
+}
+
+type setInputs struct {
+  streams []Stream
+  inputs []*setInput
+}
+
+func newSetInputs(
+    creater Creater,
+    before func(lhs, rhs interface{}) bool,
+    streams []Stream) setInputs {
+  inputs := make([]*setInput, len(streams))
+  for i := range inputs {
+    inputs[i] = &setInput{
+        stream: streams[i], current: creater(), previous: creater()}
+    inputs[i].e = streams[i].Next(inputs[i].current)
+  }
+  return setInputs{streams: streams, inputs: inputs}
+}
+
+func (s *setInputs) Close() error {
+  return closeAll(s.streams)
+}
+
+type intersectStream struct {
+  setInputs
+  copier Copier
+  before func(lhs, rhs interface{}) bool
+}
+
+func (s *intersectStream) Next(ptr interface{}) error {
+  for {
+    for _, in := range s.inputs {
+      if in.e == Done {
+        return Done
+      }
+      if in.e != nil {
+        err := in.e
+        in.advance()
+        return err
+      }
+    }
+    // Find the value that comes last, and catch the others up to it.
+    last := s.inputs[0]
+    for _, in := range s.inputs[1:] {
+      if s.before(last.current, in.current) {
+        last = in
+      }
+    }
+    inAll := true
+    for _, in := range s.inputs {
+      if s.before(in.current, last.current) {
+        in.advance()
+        inAll = false
+      }
+    }
+    if inAll {
+      s.copier(last.current, ptr)
+      for _, in := range s.inputs {
+        in.skipSame(s.before)
+      }
+      return nil
+    }
+  }
+}
+
+type differenceStream struct {
+  setInputs
+  copier Copier
+  before func(lhs, rhs interface{}) bool
+}
+
+func (s *differenceStream) Next(ptr interface{}) error {
+  first := s.inputs[0]
+  for {
+    if first.e != nil {
+      if first.e == Done {
+        return Done
+      }
+      err := first.e
+      first.advance()
+      return err
+    }
+    found := false
+    for _, in := range s.inputs[1:] {
+      for in.e == nil && s.before(in.current, first.current) {
+        in.advance()
+      }
+      if in.e != nil && in.e != Done {
+        err := in.e
+        in.advance()
+        return err
+      }
+      if in.e == nil && !s.before(first.current, in.current) {
+        found = true
+      }
+    }
+    if !found {
+      s.copier(first.current, ptr)
+      first.skipSame(s.before)
+      return nil
+    }
+    first.skipSame(s.before)
+  }
+}
@@ -0,0 +1,123 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestUnion(t *testing.T) {
+  s := Union(
+      newInt,
+      nil,
+      intBefore,
+      NewStreamFromValues([]int{1, 3, 3, 5}, nil),
+      NewStreamFromValues([]int{2, 3, 6}, nil),
+      NewStreamFromValues([]int{1, 6, 7}, nil))
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3 5 6 7]" {
+    t.Errorf("Expected [1 2 3 5 6 7] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestIntersect(t *testing.T) {
+  s := Intersect(
+      newInt,
+      nil,
+      intBefore,
+      NewStreamFromValues([]int{1, 2, 3, 3, 5, 7, 9}, nil),
+      NewStreamFromValues([]int{2, 3, 3, 4, 7, 9}, nil),
+      NewStreamFromValues([]int{0, 3, 7, 8, 9, 9}, nil))
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[3 7 9]" {
+    t.Errorf("Expected [3 7 9] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestIntersectOneEmpty(t *testing.T) {
+  s := Intersect(
+      newInt,
+      nil,
+      intBefore,
+      xrange(0, 5),
+      xrange(0, 0))
+  results, err := toIntArray(s)
+  if len(results) != 0 {
+    t.Errorf("Expected no results, got %v", results)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestIntersectError(t *testing.T) {
+  s := Intersect(
+      newInt,
+      nil,
+      intBefore,
+      Filter(
+          NewFilterer(func(ptr interface{}) error {
+            if *ptr.(*int) == 2 {
+              return scanError
+            }
+            return nil
+          }),
+          xrange(0, 5)),
+      NewStreamFromValues([]int{1, 2, 4}, nil))
+  var x int
+  expected := []error{nil, scanError, nil, Done}
+  expectedValues := []int{1, 1, 4, 4}
+  for i := range expected {
+    if err := s.Next(&x); err != expected[i] || x != expectedValues[i] {
+      t.Errorf("Expected %v %v, got %v %v", expectedValues[i], expected[i], x, err)
+    }
+  }
+}
+
+func TestDifference(t *testing.T) {
+  s := Difference(
+      newInt,
+      squareIntCopier,
+      intBefore,
+      NewStreamFromValues([]int{1, 2, 2, 3, 5, 7, 8}, nil),
+      NewStreamFromValues([]int{2, 4, 7}, nil),
+      NewStreamFromValues([]int{0, 8, 9}, nil))
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 9 25]" {
+    t.Errorf("Expected [1 9 25] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestDifferenceNoOthers(t *testing.T) {
+  s := Difference(
+      newInt, nil, intBefore, NewStreamFromValues([]int{1, 1, 2}, nil))
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 2]" {
+    t.Errorf("Expected [1 2] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestSetOpsClose(t *testing.T) {
+  for _, f := range []func(streams ...Stream) Stream{
+      func(streams ...Stream) Stream {
+        return Union(newInt, nil, intBefore, streams...)
+      },
+      func(streams ...Stream) Stream {
+        return Intersect(newInt, nil, intBefore, streams...)
+      },
+      func(streams ...Stream) Stream {
+        return Difference(newInt, nil, intBefore, streams[0], streams[1:]...)
+      }} {
+    s1 := &streamCloseChecker{xrange(0, 5), &simpleCloseChecker{}}
+    s2 := &streamCloseChecker{xrange(0, 5), &simpleCloseChecker{closeError: closeError}}
+    closeVerifyResult(t, f(s1, s2), closeError)
+    verifyCloseCalled(t, s1, true)
+    verifyCloseCalled(t, s2, true)
+  }
+}
@@ -0,0 +1,305 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "bufio"
+  "encoding/gob"
+  "io"
+  "os"
+  "sort"
+)
+
+// Encoder writes T values. Encode takes a *T.
+type Encoder interface {
+  Encode(ptr interface{}) error
+}
+
+// Decoder reads T values that an Encoder wrote. Decode takes a *T and
+// returns io.EOF when there are no more values.
+type Decoder interface {
+  Decode(ptr interface{}) error
+}
+
+// Codec of T controls how Sort stores T values in temporary files.
+type Codec interface {
+  NewEncoder(w io.Writer) Encoder
+  NewDecoder(r io.Reader) Decoder
+}
+
+// GobCodec is a Codec that uses encoding/gob. It works for any T that gob
+// can encode.
+var GobCodec Codec = gobCodec{}
+
+// sortFanIn is the most run files that Sort reads at once. It bounds the
+// number of open files. A variable so that tests can change it.
+var sortFanIn = 64
+
+// Sort returns a Stream that emits the values of s sorted according to
+// before. before returns true if the T value at lhs comes before the T value
+// at rhs. lhs and rhs are *T. Sort works for Streams too big to fit in
+// memory by sorting memLimit values at a time and writing each sorted run
+// to a temporary file. The returned Stream merges these runs with Merge.
+// Sort keeps at most 65 files open at a time: when there are too many runs
+// to merge at once, it first merges groups of them into longer runs.
+// creater is a Creater of T; copier is a Copier of T where nil means
+// regular assignment. codec encodes the T values in the temporary files;
+// nil means GobCodec. Sort panics if memLimit is less than 1.
+// Sort does no work until the first call to Next. If reading s or writing
+// the runs fails, Next reports the error once and then returns Done.
+// The sort is not stable.
+// Calling Close on returned Stream closes s and deletes the temporary files.
+func Sort(
+    s Stream,
+    before func(lhs, rhs interface{}) bool,
+    creater Creater,
+    copier Copier,
+    codec Codec,
+    memLimit int) Stream {
+  if memLimit < 1 {
+    panic("memLimit must be at least 1.")
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  if codec == nil {
+    codec = GobCodec
+  }
+  return &sortStream{
+      stream: s,
+      before: before,
+      creater: creater,
+      copier: copier,
+      codec: codec,
+      memLimit: memLimit}
+}
+
+type gobCodec struct {
+}
+
+func (g gobCodec) NewEncoder(w io.Writer) Encoder {
+  return gob.NewEncoder(w)
+}
+
+func (g gobCodec) NewDecoder(r io.Reader) Decoder {
+  return gob.NewDecoder(r)
+}
+
+type sortStream struct {
+  stream Stream
+  before func(lhs, rhs interface{}) bool
+  creater Creater
+  copier Copier
+  codec Codec
+  memLimit int
+  started bool
+  // merged emits the sorted values once started is true.
+  merged Stream
+  // runs are the names of the run files.
+  runs []string
+}
+
+func (s *sortStream) Next(ptr interface{}) error {
+  if !s.started {
+    s.started = true
+    if err := s.sort(); err != nil {
+      s.merged = nilS
+      return err
+    }
+  }
+  return s.merged.Next(ptr)
+}
+
+func (s *sortStream) Close() error {
+  var result error
+  if s.merged != nil {
+    result = s.merged.Close()
+  }
+  if err := removeFiles(s.runs); result == nil {
+    result = err
+  }
+  s.runs = nil
+  if err := s.stream.Close(); result == nil {
+    result = err
+  }
+  return result
+}
+
+// sort reads all of stream, spilling runs to files as needed, and sets
+// merged.
+func (s *sortStream) sort() (err error) {
+  values := make([]interface{}, s.memLimit)
+  for i := range values {
+    values[i] = s.creater()
+  }
+  for {
+    var n int
+    if n, err = s.readRun(values); err != nil {
+      return
+    }
+    run := values[:n]
+    sort.Slice(run, func(i, j int) bool {
+      return s.before(run[i], run[j])
+    })
+    if n < len(values) {
+      // The last run stays in memory.
+      if len(s.runs) == 0 {
+        s.merged = &ptrsStream{ptrs: run, copier: s.copier}
+        return
+      }
+      // Leave room for the run in memory.
+      for len(s.runs) >= sortFanIn {
+        if err = s.mergePass(); err != nil {
+          return
+        }
+      }
+      var streams []Stream
+      if streams, err = s.openRuns(s.runs); err != nil {
+        return
+      }
+      // Merge calls copier for us.
+      streams = append(streams, &ptrsStream{ptrs: run, copier: assignCopier})
+      s.merged = Merge(s.creater, s.copier, s.before, streams...)
+      return
+    }
+    if err = s.writeRun(&ptrsStream{ptrs: run, copier: assignCopier}); err != nil {
+      return
+    }
+  }
+}
+
+// readRun reads up to len(values) values from stream.
+func (s *sortStream) readRun(values []interface{}) (int, error) {
+  for i := range values {
+    if err := s.stream.Next(values[i]); err != nil {
+      if err == Done {
+        return i, nil
+      }
+      return 0, err
+    }
+  }
+  return len(values), nil
+}
+
+// mergePass merges each group of sortFanIn runs into a single run.
+func (s *sortStream) mergePass() error {
+  runs := s.runs
+  s.runs = nil
+  for i := 0; i < len(runs); i += sortFanIn {
+    group := runs[i:min(i + sortFanIn, len(runs))]
+    if len(group) == 1 {
+      s.runs = append(s.runs, group[0])
+      continue
+    }
+    if err := s.mergeRuns(group); err != nil {
+      s.runs = append(s.runs, runs[i:]...)
+      return err
+    }
+    if err := removeFiles(group); err != nil {
+      s.runs = append(s.runs, runs[i + len(group):]...)
+      return err
+    }
+  }
+  return nil
+}
+
+// mergeRuns merges the runs in group into a new run.
+func (s *sortStream) mergeRuns(group []string) error {
+  streams, err := s.openRuns(group)
+  if err != nil {
+    return err
+  }
+  merged := Merge(s.creater, assignCopier, s.before, streams...)
+  err = s.writeRun(merged)
+  if cerr := merged.Close(); err == nil {
+    err = cerr
+  }
+  return err
+}
+
+// writeRun writes the values of run, a Stream of T, to a new run file.
+func (s *sortStream) writeRun(run Stream) error {
+  f, err := os.CreateTemp("", "functional-sort-")
+  if err != nil {
+    return err
+  }
+  s.runs = append(s.runs, f.Name())
+  w := bufio.NewWriter(f)
+  encoder := s.codec.NewEncoder(w)
+  ptr := s.creater()
+  for err = run.Next(ptr); err == nil; err = run.Next(ptr) {
+    if err = encoder.Encode(ptr); err != nil {
+      break
+    }
+  }
+  if err == Done {
+    err = w.Flush()
+  }
+  if cerr := f.Close(); err == nil {
+    err = cerr
+  }
+  return err
+}
+
+// openRuns returns a Stream for each run file in names.
+func (s *sortStream) openRuns(names []string) ([]Stream, error) {
+  streams := make([]Stream, 0, len(names) + 1)
+  for _, name := range names {
+    f, err := os.Open(name)
+    if err != nil {
+      closeAll(streams)
+      return nil, err
+    }
+    streams = append(streams, &runStream{
+        file: f, decoder: s.codec.NewDecoder(bufio.NewReader(f))})
+  }
+  return streams, nil
+}
+
+// removeFiles removes the files in names returning the first error
+// encountered.
+func removeFiles(names []string) (result error) {
+  for _, name := range names {
+    if err := os.Remove(name); result == nil {
+      result = err
+    }
+  }
+  return
+}
+
+// runStream reads a sorted run from a temporary file.
+type runStream struct {
+  file *os.File
+  decoder Decoder
+}
+
+func (r *runStream) Next(ptr interface{}) error {
+  err := r.decoder.Decode(ptr)
+  if err == io.EOF {
+    return Done
+  }
+  return err
+}
+
+func (r *runStream) Close() error {
+  return r.file.Close()
+}
+
+// ptrsStream emits the T values that a slice of *T points to.
+type ptrsStream struct {
+  ptrs []interface{}
+  copier Copier
+  closeDoesNothing
+}
+
+func (p *ptrsStream) Next(ptr interface{}) error {
+  if len(p.ptrs) == 0 {
+    return Done
+  }
+  p.copier(p.ptrs[0], ptr)
+  p.ptrs = p.ptrs[1:]
+  return nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "io"
+    "math/rand"
+    "os"
+    "testing"
+)
+
+func TestSort(t *testing.T) {
+  dir := t.TempDir()
+  t.Setenv("TMPDIR", dir)
+  rng := rand.New(rand.NewSource(1))
+  values := rng.Perm(1000)
+  s := Sort(
+      NewStreamFromValues(values, nil), intBefore, newInt, nil, nil, 64)
+  results, err := toIntArray(s)
+  verifyDone(t, s, new(int), err)
+  if len(results) != 1000 {
+    t.Fatalf("Expected 1000 values, got %v", len(results))
+  }
+  for i := range results {
+    if results[i] != i {
+      t.Fatalf("Expected %v, got %v", i, results[i])
+    }
+  }
+  if count := countFiles(t, dir); count != 15 {
+    t.Errorf("Expected 15 run files, got %v", count)
+  }
+  closeVerifyResult(t, s, nil)
+  if count := countFiles(t, dir); count != 0 {
+    t.Errorf("Expected run files to be deleted, got %v", count)
+  }
+}
+
+func TestSortMultiplePasses(t *testing.T) {
+  dir := t.TempDir()
+  t.Setenv("TMPDIR", dir)
+  oldFanIn := sortFanIn
+  sortFanIn = 3
+  defer func() { sortFanIn = oldFanIn }()
+  rng := rand.New(rand.NewSource(2))
+  s := Sort(
+      NewStreamFromValues(rng.Perm(20), nil),
+      intBefore,
+      newInt,
+      squareIntCopier,
+      nil,
+      2)
+  results, err := toIntArray(s)
+  verifyDone(t, s, new(int), err)
+  if len(results) != 20 {
+    t.Fatalf("Expected 20 values, got %v", len(results))
+  }
+  for i := range results {
+    if results[i] != i * i {
+      t.Fatalf("Expected %v, got %v", i * i, results[i])
+    }
+  }
+  // 10 runs become 4 and then 2.
+  if count := countFiles(t, dir); count != 2 {
+    t.Errorf("Expected 2 run files, got %v", count)
+  }
+  closeVerifyResult(t, s, nil)
+  if count := countFiles(t, dir); count != 0 {
+    t.Errorf("Expected run files to be deleted, got %v", count)
+  }
+}
+
+func TestSortInMemory(t *testing.T) {
+  dir := t.TempDir()
+  t.Setenv("TMPDIR", dir)
+  s := Sort(
+      NewStreamFromValues([]int{5, 2, 7, 1}, nil),
+      intBefore,
+      newInt,
+      squareIntCopier,
+      nil,
+      10)
+  results, err := toIntArray(s)
+  verifyDone(t, s, new(int), err)
+  if output := fmt.Sprintf("%v", results); output != "[1 4 25 49]" {
+    t.Errorf("Expected [1 4 25 49] got %v", output)
+  }
+  if count := countFiles(t, dir); count != 0 {
+    t.Errorf("Expected no run files, got %v", count)
+  }
+}
+
+func TestSortCodec(t *testing.T) {
+  s := Sort(
+      NewStreamFromValues([]int{8, 3, 9, 1, 4, 6, 2}, nil),
+      intBefore,
+      newInt,
+      nil,
+      textCodec{},
+      2)
+  results, err := toIntArray(s)
+  verifyDone(t, s, new(int), err)
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3 4 6 8 9]" {
+    t.Errorf("Expected [1 2 3 4 6 8 9] got %v", output)
+  }
+  closeVerifyResult(t, s, nil)
+}
+
+func TestSortError(t *testing.T) {
+  dir := t.TempDir()
+  t.Setenv("TMPDIR", dir)
+  s := Sort(
+      Filter(
+          NewFilterer(func(ptr interface{}) error {
+            if *ptr.(*int) == 3 {
+              return scanError
+            }
+            return nil
+          }),
+          xrange(0, 5)),
+      intBefore,
+      newInt,
+      nil,
+      nil,
+      2)
+  var x int
+  if err := s.Next(&x); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  verifyDone(t, s, new(int), s.Next(&x))
+  closeVerifyResult(t, s, nil)
+  if count := countFiles(t, dir); count != 0 {
+    t.Errorf("Expected run files to be deleted, got %v", count)
+  }
+}
+
+func TestSortClose(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 5), &simpleCloseChecker{closeError: closeError}}
+  closeVerifyResult(t, Sort(s, intBefore, newInt, nil, nil, 2), closeError)
+  verifyCloseCalled(t, s, true)
+}
+
+func intBefore(lhs, rhs interface{}) bool {
+  return *lhs.(*int) < *rhs.(*int)
+}
+
+func countFiles(t *testing.T, dir string) int {
+  entries, err := os.ReadDir(dir)
+  if err != nil {
+    t.Fatalf("Error reading %v: %v", dir, err)
+  }
+  return len(entries)
+}
+
+type textCodec struct {
+}
+
+func (c textCodec) NewEncoder(w io.Writer) Encoder {
+  return textEncoder{w}
+}
+
+func (c textCodec) NewDecoder(r io.Reader) Decoder {
+  return textDecoder{r}
+}
+
+type textEncoder struct {
+  w io.Writer
+}
+
+func (e textEncoder) Encode(ptr interface{}) error {
+  _, err := fmt.Fprintln(e.w, *ptr.(*int))
+  return err
+}
+
+type textDecoder struct {
+  r io.Reader
+}
+
+func (d textDecoder) Decode(ptr interface{}) error {
+  _, err := fmt.Fscanln(d.r, ptr)
+  return err
+}
@@ -0,0 +1,158 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "sync"
+)
+
+// Tee splits s into n Streams that each emit the same values and errors as
+// s. Unlike MultiConsume, the returned Streams can be read at different
+// speeds, and each can be read from its own goroutine. Tee buffers the
+// values that the fastest returned Stream has read but the slowest has not.
+// Since this buffer holds at most maxLag values, Next on a returned Stream
+// blocks while it is maxLag values ahead of the slowest open returned Stream.
+// This means that returned Streams read from the same goroutine must stay
+// within maxLag values of each other. Closed Streams do not hold back the
+// others. creater is a Creater of T that Tee calls maxLag times to allocate
+// storage. copier is a Copier of T; nil means regular assignment.
+// Tee panics if n or maxLag is less than 1.
+// Calling Close on a returned Stream closes s only after all n returned
+// Streams are closed; the last call to Close returns the error from closing
+// s while the others return nil.
+func Tee(
+    s Stream,
+    n int,
+    creater Creater,
+    copier Copier,
+    maxLag int) []Stream {
+  if n < 1 {
+    panic("n must be at least 1.")
+  }
+  if maxLag < 1 {
+    panic("maxLag must be at least 1.")
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  source := &teeSource{
+      stream: s,
+      copier: copier,
+      entries: make([]teeEntry, maxLag),
+      positions: make([]int, n),
+      closed: make([]bool, n),
+      open: n}
+  source.cond = sync.NewCond(&source.mu)
+  for i := range source.entries {
+    source.entries[i].value = creater()
+  }
+  result := make([]Stream, n)
+  for i := range result {
+    result[i] = &teeStream{source: source, idx: i}
+  }
+  return result
+}
+
+type teeEntry struct {
+  value interface{}
+  err error
+}
+
+// teeSource is what the Streams that Tee returns share.
+type teeSource struct {
+  mu sync.Mutex
+  cond *sync.Cond
+  stream Stream
+  copier Copier
+  // entries is a ring buffer. The entry at position p is stored at
+  // index p % len(entries).
+  entries []teeEntry
+  // head is the number of entries read from stream so far.
+  head int
+  // positions[i] is the position of the next entry the ith Stream reads.
+  positions []int
+  closed []bool
+  open int
+  // reading is true while a goroutine is reading from stream.
+  reading bool
+  // done is true once stream returns Done.
+  done bool
+}
+
+// minPosition returns the position of the slowest open Stream.
+// Caller must hold mu.
+func (s *teeSource) minPosition() int {
+  result := s.head
+  for i := range s.positions {
+    if !s.closed[i] && s.positions[i] < result {
+      result = s.positions[i]
+    }
+  }
+  return result
+}
+
+type teeStream struct {
+  source *teeSource
+  idx int
+}
+
+func (t *teeStream) Next(ptr interface{}) error {
+  s := t.source
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  for {
+    pos := s.positions[t.idx]
+    if pos < s.head {
+      entry := &s.entries[pos % len(s.entries)]
+      if entry.err == nil {
+        s.copier(entry.value, ptr)
+      }
+      s.positions[t.idx]++
+      s.cond.Broadcast()
+      return entry.err
+    }
+    if s.done {
+      return Done
+    }
+    if !s.reading && s.head - s.minPosition() < len(s.entries) {
+      // No open Stream needs the entry at head - len(entries) anymore,
+      // so we can read into its storage without holding mu.
+      s.reading = true
+      entry := &s.entries[s.head % len(s.entries)]
+      s.mu.Unlock()
+      err := s.stream.Next(entry.value)
+      s.mu.Lock()
+      if err == Done {
+        s.done = true
+      } else {
+        entry.err = err
+        s.head++
+      }
+      s.reading = false
+      s.cond.Broadcast()
+      continue
+    }
+    s.cond.Wait()
+  }
+}
+
+func (t *teeStream) Close() error {
+  s := t.source
+  s.mu.Lock()
+  if s.closed[t.idx] {
+    s.mu.Unlock()
+    return nil
+  }
+  s.closed[t.idx] = true
+  s.open--
+  lastOne := s.open == 0
+  s.cond.Broadcast()
+  s.mu.Unlock()
+  if lastOne {
+    return s.stream.Close()
+  }
+  return nil
+}
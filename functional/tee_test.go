@@ -0,0 +1,120 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestTee(t *testing.T) {
+  streams := Tee(xrange(0, 5), 2, newInt, nil, 2)
+  a, b := streams[0], streams[1]
+  // Neither Stream may get more than 2 values ahead of the other.
+  aResults := readInts(t, a, 2)
+  bResults := readInts(t, b, 4)
+  aResults = append(aResults, readInts(t, a, 3)...)
+  bResults = append(bResults, readInts(t, b, 1)...)
+  if output := fmt.Sprintf("%v %v", aResults, bResults); output != "[0 1 2 3 4] [0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] [0 1 2 3 4] got %v", output)
+  }
+  verifyDone(t, a, new(int), a.Next(new(int)))
+  verifyDone(t, b, new(int), b.Next(new(int)))
+}
+
+func TestTeeConcurrent(t *testing.T) {
+  streams := Tee(xrange(0, 100), 3, newInt, nil, 4)
+  results := make([][]int, len(streams))
+  errs := make([]error, len(streams))
+  var wg sync.WaitGroup
+  for i := range streams {
+    wg.Add(1)
+    go func(i int) {
+      defer wg.Done()
+      var x int
+      err := streams[i].Next(&x)
+      for ; err == nil; err = streams[i].Next(&x) {
+        if i == 0 && x % 10 == 0 {
+          time.Sleep(time.Millisecond)
+        }
+        results[i] = append(results[i], x)
+      }
+      errs[i] = err
+    }(i)
+  }
+  wg.Wait()
+  for i := range streams {
+    if errs[i] != Done {
+      t.Errorf("Expected Done, got %v", errs[i])
+    }
+    if len(results[i]) != 100 {
+      t.Fatalf("Expected 100 values, got %v", len(results[i]))
+    }
+    for j := range results[i] {
+      if results[i][j] != j {
+        t.Fatalf("Expected %v, got %v", j, results[i][j])
+      }
+    }
+  }
+}
+
+func TestTeeErrors(t *testing.T) {
+  s := Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if *ptr.(*int) == 1 {
+          return scanError
+        }
+        return nil
+      }),
+      xrange(0, 3))
+  streams := Tee(s, 2, newInt, squareIntCopier, 4)
+  expected := []error{nil, scanError, nil, Done, Done}
+  for _, stream := range streams {
+    var x int
+    for i := range expected {
+      if err := stream.Next(&x); err != expected[i] {
+        t.Errorf("Expected %v, got %v", expected[i], err)
+      }
+    }
+    if x != 4 {
+      t.Errorf("Expected 4, got %v", x)
+    }
+  }
+}
+
+func TestTeeClosedStreamDoesNotBlock(t *testing.T) {
+  streams := Tee(Count(), 2, newInt, nil, 1)
+  closeVerifyResult(t, streams[1], nil)
+  stream := Slice(streams[0], 0, 5)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestTeeClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{closeError: closeError}}
+  streams := Tee(s, 3, newInt, nil, 2)
+  closeVerifyResult(t, streams[0], nil)
+  closeVerifyResult(t, streams[2], nil)
+  closeVerifyResult(t, streams[0], nil)
+  verifyCloseCalled(t, s, false)
+  closeVerifyResult(t, streams[1], closeError)
+  verifyCloseCalled(t, s, true)
+}
+
+func readInts(t *testing.T, s Stream, n int) []int {
+  result := make([]int, n)
+  for i := range result {
+    if err := s.Next(&result[i]); err != nil {
+      t.Fatalf("Expected nil, got %v", err)
+    }
+  }
+  return result
+}
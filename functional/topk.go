@@ -0,0 +1,131 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "container/heap"
+  "sort"
+)
+
+// TopK returns a Stream that emits the k values of s that come last
+// according to before, starting with the very last. before returns true if
+// the T value at lhs comes before the T value at rhs. lhs and rhs are *T.
+// TopK keeps only k values in memory at once. creater is a Creater of T
+// that TopK calls k + 1 times; copier is a Copier of T where nil means
+// regular assignment. TopK panics if k is less than 1.
+// TopK reads all of s the first time caller calls Next. If s reports an
+// error, Next reports that error once and then returns Done.
+// Calling Close on returned Stream closes s.
+func TopK(
+    s Stream,
+    k int,
+    before func(lhs, rhs interface{}) bool,
+    creater Creater,
+    copier Copier) Stream {
+  return BottomK(
+      s,
+      k,
+      func(lhs, rhs interface{}) bool { return before(rhs, lhs) },
+      creater,
+      copier)
+}
+
+// BottomK works like TopK except that it emits the k values of s that come
+// first according to before, starting with the very first.
+// Calling Close on returned Stream closes s.
+func BottomK(
+    s Stream,
+    k int,
+    before func(lhs, rhs interface{}) bool,
+    creater Creater,
+    copier Copier) Stream {
+  if k < 1 {
+    panic("k must be at least 1.")
+  }
+  if copier == nil {
+    copier = assignCopier
+  }
+  return &bottomKStream{
+      stream: s, k: k, before: before, creater: creater, copier: copier}
+}
+
+type bottomKStream struct {
+  stream Stream
+  k int
+  before func(lhs, rhs interface{}) bool
+  creater Creater
+  copier Copier
+  // results emits the values once Next has been called.
+  results Stream
+}
+
+func (s *bottomKStream) Next(ptr interface{}) error {
+  if s.results == nil {
+    values, err := s.bottomK()
+    if err != nil {
+      s.results = nilS
+      return err
+    }
+    s.results = &ptrsStream{ptrs: values, copier: s.copier}
+  }
+  return s.results.Next(ptr)
+}
+
+func (s *bottomKStream) Close() error {
+  return s.stream.Close()
+}
+
+// bottomK returns the first k values of stream in order.
+func (s *bottomKStream) bottomK() ([]interface{}, error) {
+  h := &boundedHeap{before: s.before}
+  spare := s.creater()
+  for err := s.stream.Next(spare); err != Done; err = s.stream.Next(spare) {
+    if err != nil {
+      return nil, err
+    }
+    if h.Len() < s.k {
+      heap.Push(h, spare)
+      spare = s.creater()
+    } else if s.before(spare, h.items[0]) {
+      // Replace the value that comes last, reusing its storage.
+      h.items[0], spare = spare, h.items[0]
+      heap.Fix(h, 0)
+    }
+  }
+  sort.Slice(h.items, func(i, j int) bool {
+    return s.before(h.items[i], h.items[j])
+  })
+  return h.items, nil
+}
+
+// boundedHeap is a heap of *T with the T value that comes last at the top.
+type boundedHeap struct {
+  items []interface{}
+  before func(lhs, rhs interface{}) bool
+}
+
+func (h *boundedHeap) Len() int {
+  return len(h.items)
+}
+
+func (h *boundedHeap) Less(i, j int) bool {
+  return h.before(h.items[j], h.items[i])
+}
+
+func (h *boundedHeap) Swap(i, j int) {
+  h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *boundedHeap) Push(x interface{}) {
+  h.items = append(h.items, x)
+}
+
+func (h *boundedHeap) Pop() interface{} {
+  old := h.items
+  n := len(old)
+  h.items = old[0:n - 1]
+  return old[n - 1]
+}
@@ -0,0 +1,73 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "math/rand"
+    "testing"
+)
+
+func TestTopK(t *testing.T) {
+  values := rand.New(rand.NewSource(1)).Perm(100)
+  s := TopK(NewStreamFromValues(values, nil), 5, intBefore, newInt, nil)
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[99 98 97 96 95]" {
+    t.Errorf("Expected [99 98 97 96 95] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestBottomK(t *testing.T) {
+  values := rand.New(rand.NewSource(1)).Perm(100)
+  s := BottomK(NewStreamFromValues(values, nil), 5, intBefore, newInt, nil)
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestTopKShortStream(t *testing.T) {
+  s := TopK(
+      NewStreamFromValues([]int{3, 1, 2}, nil),
+      5,
+      intBefore,
+      newInt,
+      squareIntCopier)
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[9 4 1]" {
+    t.Errorf("Expected [9 4 1] got %v", output)
+  }
+  verifyDone(t, s, new(int), err)
+}
+
+func TestTopKError(t *testing.T) {
+  s := TopK(
+      Filter(
+          NewFilterer(func(ptr interface{}) error {
+            if *ptr.(*int) == 3 {
+              return scanError
+            }
+            return nil
+          }),
+          xrange(0, 5)),
+      2,
+      intBefore,
+      newInt,
+      nil)
+  var x int
+  if err := s.Next(&x); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  verifyDone(t, s, new(int), s.Next(&x))
+}
+
+func TestTopKClose(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 5), &simpleCloseChecker{closeError: closeError}}
+  closeVerifyResult(t, TopK(s, 2, intBefore, newInt, nil), closeError)
+  verifyCloseCalled(t, s, true)
+}
@@ -0,0 +1,197 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+// Package typed provides a type safe layer over the functional package.
+// A Stream[T] can be converted to a functional.Stream and back so that
+// existing sources such as functional.ReadLines and functional.ReadRows and
+// existing consumers continue to work with typed Streams.
+package typed
+
+import (
+  "github.com/keep94/gofunctional3/functional"
+  "io"
+)
+
+// Stream is a sequence of emitted T values.
+// Each call to Next() emits the next value in the stream.
+type Stream[T any] interface {
+  // Next emits the next value in this Stream.
+  // If Next returns nil, the next value is stored at ptr.
+  // If Next returns functional.Done, then the end of the Stream has been
+  // reached, and the value ptr points to is unspecified.
+  // Once Next returns functional.Done, it should continue to return
+  // functional.Done.
+  Next(ptr *T) error
+  // Caller calls Close when it is finished with this Stream.
+  // The result of calling Next after Close is unspecified.
+  io.Closer
+}
+
+// Filterer filters values in a Stream[T].
+type Filterer[T any] interface {
+  // Filter returns nil if value ptr points to should be included or
+  // functional.Skipped if value should be skipped.
+  Filter(ptr *T) error
+}
+
+// Mapper maps a type T value to a type U value in a Stream.
+type Mapper[T, U any] interface {
+  // Map does the mapping storing the mapped value at destPtr.
+  // If Mapper returns functional.Skipped, then no mapped value is stored at
+  // destPtr. Map may return other errors.
+  Map(srcPtr *T, destPtr *U) error
+}
+
+// NewFilterer returns a new Filterer[T]. f returns nil if the T value ptr
+// points to should be included or functional.Skipped if it should not be
+// included. f can return other errors too.
+func NewFilterer[T any](f func(ptr *T) error) Filterer[T] {
+  return funcFilterer[T](f)
+}
+
+// NewMapper returns a new Mapper mapping T values to U values. f returns
+// functional.Skipped if mapped value should be skipped. f can also return
+// other errors.
+func NewMapper[T, U any](m func(srcPtr *T, destPtr *U) error) Mapper[T, U] {
+  return funcMapper[T, U](m)
+}
+
+// FromStream converts s, a functional.Stream of T, to a Stream[T].
+// Calling Close on returned Stream closes s.
+func FromStream[T any](s functional.Stream) Stream[T] {
+  if us, ok := s.(untypedStream[T]); ok {
+    return us.Stream
+  }
+  return typedStream[T]{s}
+}
+
+// ToStream converts s to a functional.Stream of T.
+// Calling Close on returned Stream closes s.
+func ToStream[T any](s Stream[T]) functional.Stream {
+  if ts, ok := s.(typedStream[T]); ok {
+    return ts.Stream
+  }
+  return untypedStream[T]{s}
+}
+
+// ToFilterer converts f to a functional.Filterer of T.
+func ToFilterer[T any](f Filterer[T]) functional.Filterer {
+  return functional.NewFilterer(func(ptr interface{}) error {
+    return f.Filter(ptr.(*T))
+  })
+}
+
+// ToMapper converts m to a functional.Mapper mapping T values to U values.
+func ToMapper[T, U any](m Mapper[T, U]) functional.Mapper {
+  return functional.NewMapper(func(srcPtr, destPtr interface{}) error {
+    return m.Map(srcPtr.(*T), destPtr.(*U))
+  })
+}
+
+// Map applies m to s producing a new Stream[U]. If s is (x1, x2, x3, ...),
+// Map returns the Stream (m(x1), m(x2), m(x3), ...). If m returns
+// functional.Skipped for a T value, then the corresponding U value is left
+// out of the returned Stream.
+// Calling Close on returned Stream closes s.
+func Map[T, U any](m Mapper[T, U], s Stream[T]) Stream[U] {
+  return FromStream[U](functional.Map(ToMapper(m), ToStream(s), new(T)))
+}
+
+// Filter filters values from s, returning a new Stream[T]. The returned
+// Stream's Next method reports any errors besides functional.Skipped that
+// the Filter method of f returns.
+// Calling Close on returned Stream closes s.
+func Filter[T any](f Filterer[T], s Stream[T]) Stream[T] {
+  return FromStream[T](functional.Filter(ToFilterer(f), ToStream(s)))
+}
+
+// Slice returns a Stream that will emit elements in s starting at index start
+// and continuing to but not including index end. Indexes are 0 based. If end
+// is negative, it means go to the end of s.
+// Calling Close on returned Stream closes s.
+func Slice[T any](s Stream[T], start int, end int) Stream[T] {
+  return FromStream[T](functional.Slice(ToStream(s), start, end))
+}
+
+// Merge merges multiple streams that emit their elements in order according
+// to before into a single stream that emits all the elements in order.
+// before returns true if the T value at lhs comes before the T value at rhs.
+// Calling Close on returned Stream closes all underlying streams.
+func Merge[T any](before func(lhs, rhs *T) bool, streams ...Stream[T]) Stream[T] {
+  return FromStream[T](functional.Merge(
+      creater[T],
+      copier[T],
+      func(lhs, rhs interface{}) bool {
+        return before(lhs.(*T), rhs.(*T))
+      },
+      toStreams(streams)...))
+}
+
+// Concat concatenates multiple Streams into one.
+// Calling Close on returned Stream closes all underlying streams.
+func Concat[T any](s ...Stream[T]) Stream[T] {
+  return FromStream[T](functional.Concat(toStreams(s)...))
+}
+
+// Flatten converts a Stream of Stream[T] into a Stream[T].
+// The returned Stream automatically closes each emitted Stream from s
+// propagating any error from closing through Next.
+// Calling Close on returned Stream closes s and the last emitted Stream
+// from s currently being read.
+func Flatten[T any](s Stream[Stream[T]]) Stream[T] {
+  m := NewMapper(func(srcPtr *Stream[T], destPtr *functional.Stream) error {
+    *destPtr = ToStream(*srcPtr)
+    return nil
+  })
+  return FromStream[T](functional.Flatten(ToStream(Map(m, s))))
+}
+
+type typedStream[T any] struct {
+  functional.Stream
+}
+
+func (s typedStream[T]) Next(ptr *T) error {
+  return s.Stream.Next(ptr)
+}
+
+type untypedStream[T any] struct {
+  Stream Stream[T]
+}
+
+func (s untypedStream[T]) Next(ptr interface{}) error {
+  return s.Stream.Next(ptr.(*T))
+}
+
+func (s untypedStream[T]) Close() error {
+  return s.Stream.Close()
+}
+
+type funcFilterer[T any] func(ptr *T) error
+
+func (f funcFilterer[T]) Filter(ptr *T) error {
+  return f(ptr)
+}
+
+type funcMapper[T, U any] func(srcPtr *T, destPtr *U) error
+
+func (m funcMapper[T, U]) Map(srcPtr *T, destPtr *U) error {
+  return m(srcPtr, destPtr)
+}
+
+func toStreams[T any](s []Stream[T]) []functional.Stream {
+  result := make([]functional.Stream, len(s))
+  for i := range s {
+    result[i] = ToStream(s[i])
+  }
+  return result
+}
+
+func creater[T any]() interface{} {
+  return new(T)
+}
+
+func copier[T any](src, dest interface{}) {
+  *dest.(*T) = *src.(*T)
+}
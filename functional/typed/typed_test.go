@@ -0,0 +1,167 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package typed
+
+import (
+  "errors"
+  "fmt"
+  "github.com/keep94/gofunctional3/functional"
+  "strings"
+  "testing"
+)
+
+var (
+  closeError = errors.New("error closing.")
+  mapError = errors.New("map error.")
+)
+
+func TestMapAndFilter(t *testing.T) {
+  even := NewFilterer(func(ptr *int) error {
+    if *ptr % 2 == 0 {
+      return nil
+    }
+    return functional.Skipped
+  })
+  square := NewMapper(func(srcPtr *int, destPtr *string) error {
+    *destPtr = fmt.Sprintf("%d", (*srcPtr) * (*srcPtr))
+    return nil
+  })
+  stream := Map(square, Filter(even, xrange(0, 7)))
+  results, err := toArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 4 16 36]" {
+    t.Errorf("Expected [0 4 16 36] got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestMapSkipAndError(t *testing.T) {
+  m := NewMapper(func(srcPtr *int, destPtr *int) error {
+    switch *srcPtr {
+    case 1:
+      return functional.Skipped
+    case 3:
+      return mapError
+    }
+    *destPtr = *srcPtr
+    return nil
+  })
+  stream := Map(m, xrange(0, 5))
+  var x int
+  expected := []error{nil, nil, mapError, nil, functional.Done}
+  for i := range expected {
+    if err := stream.Next(&x); err != expected[i] {
+      t.Errorf("Expected %v, got %v", expected[i], err)
+    }
+  }
+}
+
+func TestSliceAndConcat(t *testing.T) {
+  stream := Concat(Slice(xrange(0, 10), 2, 4), xrange(20, 22))
+  results, err := toArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[2 3 20 21]" {
+    t.Errorf("Expected [2 3 20 21] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestMerge(t *testing.T) {
+  stream := Merge(
+      func(lhs, rhs *int) bool { return *lhs < *rhs },
+      fromValues(5, 7, 10),
+      fromValues(3, 7, 8, 11),
+      fromValues(6))
+  results, err := toArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[3 5 6 7 7 8 10 11]" {
+    t.Errorf("Expected [3 5 6 7 7 8 10 11] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestFlatten(t *testing.T) {
+  s := fromValues(xrange(0, 2), xrange(5, 7), fromValues[int]())
+  stream := Flatten(s)
+  results, err := toArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 5 6]" {
+    t.Errorf("Expected [0 1 5 6] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestReadLines(t *testing.T) {
+  stream := FromStream[string](
+      functional.ReadLines(strings.NewReader("Hello\nWorld\n")))
+  results, err := toArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[Hello World]" {
+    t.Errorf("Expected [Hello World] got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestRoundTrip(t *testing.T) {
+  s := functional.Count()
+  if output := ToStream(FromStream[int](s)); output != s {
+    t.Error("Expected round trip to return original functional.Stream.")
+  }
+  ts := xrange(0, 3)
+  if output := FromStream[int](ToStream(ts)); output != ts {
+    t.Error("Expected round trip to return original Stream.")
+  }
+}
+
+func TestClose(t *testing.T) {
+  x := &closeChecker{Stream: xrange(0, 3), closeError: closeError}
+  y := &closeChecker{Stream: xrange(0, 3)}
+  stream := Map(
+      NewMapper(func(srcPtr *int, destPtr *int) error {
+        *destPtr = *srcPtr
+        return nil
+      }),
+      Concat[int](x, y))
+  if err := stream.Close(); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  if !x.closeCalled || !y.closeCalled {
+    t.Error("Expected close to be called.")
+  }
+}
+
+type closeChecker struct {
+  Stream[int]
+  closeError error
+  closeCalled bool
+}
+
+func (c *closeChecker) Close() error {
+  c.closeCalled = true
+  return c.closeError
+}
+
+func verifyDone[T any](t *testing.T, s Stream[T], ptr *T, err error) {
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if output := s.Next(ptr); output != functional.Done {
+    t.Errorf("Expected Next to keep returning Done, got %v", output)
+  }
+}
+
+func xrange(start, end int) Stream[int] {
+  return FromStream[int](functional.Slice(functional.Count(), start, end))
+}
+
+func fromValues[T any](values ...T) Stream[T] {
+  return FromStream[T](functional.NewStreamFromValues(values, nil))
+}
+
+func toArray[T any](s Stream[T]) ([]T, error) {
+  var result []T
+  var x T
+  err := s.Next(&x)
+  for ; err == nil; err = s.Next(&x) {
+    result = append(result, x)
+  }
+  return result, err
+}
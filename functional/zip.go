@@ -0,0 +1,122 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "errors"
+)
+
+var (
+  // ErrLengthMismatch indicates that the Streams passed to ZipStrict
+  // emitted different numbers of values.
+  ErrLengthMismatch = errors.New("functional: Streams have different lengths.")
+)
+
+// Zip returns a Stream of Tuple that walks streams in lockstep. Each emitted
+// Tuple has one field for each Stream in streams: the Next method of the
+// returned Stream fills the ith pointer in Ptrs() by calling Next on the
+// ith Stream. The returned Stream ends as soon as any of streams ends.
+// If a Stream in streams reports an error, Next reports it, and calling Next
+// again with the same Tuple resumes filling it with the next Stream.
+// Calling Close on returned Stream closes all underlying streams.
+// If caller passes a slice to Zip, no copy is made of it.
+func Zip(streams ...Stream) Stream {
+  if len(streams) == 0 {
+    return nilS
+  }
+  return &zipStream{streams: streams}
+}
+
+// ZipLongest works like Zip except that the returned Stream ends only when
+// all of streams end. Once a Stream in streams ends, the corresponding field
+// of each emitted Tuple gets the corresponding field of fill instead.
+// fill creates the Tuple holding the fill values; ZipLongest calls it once.
+// Calling Close on returned Stream closes all underlying streams.
+// If caller passes a slice to ZipLongest, no copy is made of it.
+func ZipLongest(fill Creater, streams ...Stream) Stream {
+  if len(streams) == 0 {
+    return nilS
+  }
+  return &zipStream{
+      streams: streams,
+      fill: fill().(Tuple).Ptrs(),
+      exhausted: make([]bool, len(streams))}
+}
+
+// ZipStrict works like Zip except that if streams do not all emit the same
+// number of values, Next reports ErrLengthMismatch instead of Done once
+// the shortest Stream ends, and then returns Done after that.
+// Calling Close on returned Stream closes all underlying streams.
+// If caller passes a slice to ZipStrict, no copy is made of it.
+func ZipStrict(streams ...Stream) Stream {
+  if len(streams) == 0 {
+    return nilS
+  }
+  return &zipStream{streams: streams, strict: true}
+}
+
+type zipStream struct {
+  streams []Stream
+  // fill and exhausted are non-nil for ZipLongest.
+  fill []interface{}
+  exhausted []bool
+  strict bool
+  // idx is the index of the next Stream to read within the current Tuple.
+  idx int
+  // emitting is true if any Stream emitted a value for the current Tuple.
+  emitting bool
+  // ending is true if the first Stream ended in strict mode.
+  ending bool
+  done bool
+}
+
+func (s *zipStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  ptrs := ptr.(Tuple).Ptrs()
+  for ; s.idx < len(s.streams); s.idx++ {
+    if s.exhausted != nil && s.exhausted[s.idx] {
+      assignCopier(s.fill[s.idx], ptrs[s.idx])
+      continue
+    }
+    err := s.streams[s.idx].Next(ptrs[s.idx])
+    if err == nil {
+      if s.ending {
+        s.done = true
+        return ErrLengthMismatch
+      }
+      s.emitting = true
+      continue
+    }
+    if err != Done {
+      return err
+    }
+    if s.exhausted != nil {
+      s.exhausted[s.idx] = true
+      assignCopier(s.fill[s.idx], ptrs[s.idx])
+    } else if !s.strict {
+      s.done = true
+      return Done
+    } else if s.idx > 0 && !s.ending {
+      s.done = true
+      return ErrLengthMismatch
+    } else {
+      s.ending = true
+    }
+  }
+  s.idx = 0
+  if s.ending || !s.emitting {
+    s.done = true
+    return Done
+  }
+  s.emitting = false
+  return nil
+}
+
+func (s *zipStream) Close() error {
+  return closeAll(s.streams)
+}
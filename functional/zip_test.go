@@ -0,0 +1,112 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestZip(t *testing.T) {
+  stream := Zip(xrange(0, 5), stringStream("a", "b", "c"))
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{0 a} {1 b} {2 c}]" {
+    t.Errorf("Expected [{0 a} {1 b} {2 c}] got %v", output)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+}
+
+func TestZipEmpty(t *testing.T) {
+  if Zip() != NilStream() {
+    t.Error("Expected Zip of nothing to be the nil stream.")
+  }
+}
+
+func TestZipError(t *testing.T) {
+  strs := Filter(
+      NewFilterer(func(ptr interface{}) error {
+        if *ptr.(*string) == "b" {
+          return scanError
+        }
+        return nil
+      }),
+      stringStream("a", "b", "c"))
+  stream := Zip(xrange(0, 3), strs)
+  var x intAndString
+  expected := []error{nil, scanError, nil}
+  for i := range expected {
+    if err := stream.Next(&x); err != expected[i] {
+      t.Errorf("Expected %v, got %v", expected[i], err)
+    }
+  }
+  if output := fmt.Sprintf("%v", x); output != "{1 c}" {
+    t.Errorf("Expected {1 c} got %v", output)
+  }
+  verifyDone(t, stream, &x, stream.Next(&x))
+}
+
+func TestZipLongest(t *testing.T) {
+  fill := func() interface{} {
+    return &intAndString{id: -1, name: "none"}
+  }
+  stream := ZipLongest(fill, xrange(0, 4), stringStream("a", "b"))
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{0 a} {1 b} {2 none} {3 none}]" {
+    t.Errorf("Expected [{0 a} {1 b} {2 none} {3 none}] got %v", output)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+
+  stream = ZipLongest(fill, xrange(0, 1), stringStream("a", "b"))
+  results, err = toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{0 a} {-1 b}]" {
+    t.Errorf("Expected [{0 a} {-1 b}] got %v", output)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+}
+
+func TestZipStrict(t *testing.T) {
+  stream := ZipStrict(xrange(0, 2), stringStream("a", "b"))
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{0 a} {1 b}]" {
+    t.Errorf("Expected [{0 a} {1 b}] got %v", output)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+}
+
+func TestZipStrictMismatch(t *testing.T) {
+  stream := ZipStrict(xrange(0, 3), stringStream("a", "b"))
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{0 a} {1 b}]" {
+    t.Errorf("Expected [{0 a} {1 b}] got %v", output)
+  }
+  if err != ErrLengthMismatch {
+    t.Errorf("Expected ErrLengthMismatch, got %v", err)
+  }
+  verifyDone(t, stream, new(intAndString), Done)
+
+  stream = ZipStrict(xrange(0, 1), stringStream("a", "b"))
+  results, err = toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{0 a}]" {
+    t.Errorf("Expected [{0 a}] got %v", output)
+  }
+  if err != ErrLengthMismatch {
+    t.Errorf("Expected ErrLengthMismatch, got %v", err)
+  }
+  verifyDone(t, stream, new(intAndString), Done)
+}
+
+func TestZipClose(t *testing.T) {
+  x := &streamCloseChecker{NilStream(), &simpleCloseChecker{closeError: closeError}}
+  y := &streamCloseChecker{NilStream(), &simpleCloseChecker{}}
+  stream := Zip(x, y)
+  closeVerifyResult(t, stream, closeError)
+  verifyCloseCalled(t, x, true)
+  verifyCloseCalled(t, y, true)
+}
+
+func stringStream(values ...string) Stream {
+  return NewStreamFromValues(values, nil)
+}